@@ -0,0 +1,39 @@
+// Package chiadapter monte les handlers de httpapi sur un routeur chi.
+package chiadapter
+
+import (
+	"net/http"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/go-chi/chi/v5"
+)
+
+// Register enregistre les routes de paiement, de statut, de solde, de callbacks et
+// les flux de statut.
+func Register(r chi.Router, h *httpapi.Handlers) {
+	r.Post(httpapi.RequestToPayPath, Wrap(h.RequestToPay))
+	r.Get(httpapi.PaymentStatusPath, Wrap(h.PaymentStatus))
+	r.Get(httpapi.BalancePath, Wrap(h.Balance))
+	r.Get(httpapi.PaymentEventsPath, Wrap(h.PaymentEvents))
+	r.Get(httpapi.PaymentSocketPath, Wrap(h.PaymentSocket))
+
+	callbacks := Wrap(h.Callbacks().ServeHTTP)
+	r.Put(httpapi.CallbacksPath+"*", callbacks)
+	r.Post(httpapi.CallbacksPath+"*", callbacks)
+}
+
+// Wrap recopie les paramètres d'URL chi dans r.PathValue et attribue un identifiant
+// de corrélation à la requête.
+func Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r = httpapi.WithCorrelationID(w, r)
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			for i, key := range rctx.URLParams.Keys {
+				if key != "*" {
+					r.SetPathValue(key, rctx.URLParams.Values[i])
+				}
+			}
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,15 @@
+package chiadapter
+
+import (
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/internal/momotest"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRegister(t *testing.T) {
+	r := chi.NewRouter()
+	Register(r, httpapi.New(momotest.NewClient(t), nil))
+	momotest.CheckRoutes(t, r)
+}
@@ -0,0 +1,46 @@
+// Package echoadapter monte les handlers de httpapi sur un routeur echo.
+package echoadapter
+
+import (
+	"net/http"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/labstack/echo/v4"
+)
+
+// Router est implémenté par *echo.Echo et *echo.Group.
+type Router interface {
+	GET(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	POST(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+	PUT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+}
+
+// Register enregistre les routes de paiement, de statut, de solde, de callbacks et
+// les flux de statut.
+func Register(r Router, h *httpapi.Handlers) {
+	r.POST(httpapi.RequestToPayPath, Wrap(h.RequestToPay))
+	r.GET("/payment-status/:"+httpapi.ReferenceIDParam, Wrap(h.PaymentStatus))
+	r.GET(httpapi.BalancePath, Wrap(h.Balance))
+	r.GET("/payments/:"+httpapi.ReferenceIDParam+"/events", Wrap(h.PaymentEvents))
+	r.GET("/payments/:"+httpapi.ReferenceIDParam+"/ws", Wrap(h.PaymentSocket))
+
+	callbacks := Wrap(h.Callbacks().ServeHTTP)
+	r.PUT(httpapi.CallbacksPath+"*", callbacks)
+	r.POST(httpapi.CallbacksPath+"*", callbacks)
+}
+
+// Wrap convertit un handler net/http en handler echo. Les paramètres de chemin echo sont
+// recopiés dans r.PathValue, et un identifiant de corrélation est attribué à la requête.
+func Wrap(h http.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		w := c.Response()
+		r := httpapi.WithCorrelationID(w, c.Request())
+		for _, name := range c.ParamNames() {
+			if name != "*" {
+				r.SetPathValue(name, c.Param(name))
+			}
+		}
+		h(w, r)
+		return nil
+	}
+}
@@ -0,0 +1,15 @@
+package echoadapter
+
+import (
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/internal/momotest"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRegister(t *testing.T) {
+	e := echo.New()
+	Register(e, httpapi.New(momotest.NewClient(t), nil))
+	momotest.CheckRoutes(t, e)
+}
@@ -0,0 +1,35 @@
+// Package ginadapter monte les handlers de httpapi sur un routeur gin.
+package ginadapter
+
+import (
+	"net/http"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/gin-gonic/gin"
+)
+
+// Register enregistre les routes de paiement, de statut, de solde, de callbacks et
+// les flux de statut.
+func Register(r gin.IRoutes, h *httpapi.Handlers) {
+	r.POST(httpapi.RequestToPayPath, Wrap(h.RequestToPay))
+	r.GET("/payment-status/:"+httpapi.ReferenceIDParam, Wrap(h.PaymentStatus))
+	r.GET(httpapi.BalancePath, Wrap(h.Balance))
+	r.GET("/payments/:"+httpapi.ReferenceIDParam+"/events", Wrap(h.PaymentEvents))
+	r.GET("/payments/:"+httpapi.ReferenceIDParam+"/ws", Wrap(h.PaymentSocket))
+
+	callbacks := Wrap(h.Callbacks().ServeHTTP)
+	r.PUT(httpapi.CallbacksPath+"*path", callbacks)
+	r.POST(httpapi.CallbacksPath+"*path", callbacks)
+}
+
+// Wrap convertit un handler net/http en handler gin. Les paramètres de chemin gin sont
+// recopiés dans r.PathValue, et un identifiant de corrélation est attribué à la requête.
+func Wrap(h http.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := httpapi.WithCorrelationID(c.Writer, c.Request)
+		for _, param := range c.Params {
+			r.SetPathValue(param.Key, param.Value)
+		}
+		h(c.Writer, r)
+	}
+}
@@ -0,0 +1,16 @@
+package ginadapter
+
+import (
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/internal/momotest"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Register(r, httpapi.New(momotest.NewClient(t), nil))
+	momotest.CheckRoutes(t, r)
+}
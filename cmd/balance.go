@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/spf13/cobra"
+)
+
+var balanceCurrency string
+
+// productBalance is the balance of one product, or the error that prevented reading it.
+type productBalance struct {
+	Product          momo.Product `json:"product"`
+	AvailableBalance string       `json:"availableBalance,omitempty"`
+	Currency         string       `json:"currency,omitempty"`
+	Error            string       `json:"error,omitempty"`
+}
+
+// balanceCmd represents the balance command
+var balanceCmd = &cobra.Command{
+	Use:   "balance",
+	Short: "Show the account balance of each product",
+	Long: `Read the account balance of each product that has credentials: collection,
+disbursement and remittance. With --currency, MoMo converts the balance to
+that currency. The exit code is 1 if any balance cannot be read.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := mustMomoClient()
+		products := productClients(client)
+
+		balances := make([]productBalance, 0, len(products))
+		failed := false
+		for _, p := range products {
+			var balance *momo.Balance
+			var err error
+			if balanceCurrency != "" {
+				balance, err = p.GetAccountBalanceInCurrency(balanceCurrency)
+			} else {
+				balance, err = p.GetAccountBalance()
+			}
+
+			entry := productBalance{Product: p.Product}
+			if err != nil {
+				entry.Error = err.Error()
+				failed = true
+			} else {
+				entry.AvailableBalance = balance.AvailableBalance
+				entry.Currency = balance.Currency
+			}
+			balances = append(balances, entry)
+		}
+		keepTokens(client)
+
+		if outputJSON {
+			printJSON(balances)
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PRODUCT\tBALANCE\tCURRENCY")
+			for _, entry := range balances {
+				if entry.Error != "" {
+					fmt.Fprintf(w, "%s\terror: %s\t\n", entry.Product, entry.Error)
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Product, entry.AvailableBalance, entry.Currency)
+			}
+			w.Flush()
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(balanceCmd)
+
+	balanceCmd.Flags().StringVar(&balanceCurrency, "currency", "", "ISO 4217 currency to convert the balance to")
+	balanceCmd.Flags().BoolVar(&outputJSON, "json", false, "print the balances as JSON")
+}
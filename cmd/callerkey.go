@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/enzoforreal/mtn-momo-api/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	callerKeyID     string
+	callerKeyScopes []string
+	callerKeysFile  string
+)
+
+// callerKeyCmd represents the caller-key command
+var callerKeyCmd = &cobra.Command{
+	Use:   "caller-key",
+	Short: "Generate a scoped API key for a gateway caller",
+	Long: `Generate a random API key for a gateway caller and append its SHA-256 hash
+and scopes to the caller keys file read through GATEWAY_CALLER_KEYS_FILE.
+
+The key itself is printed once and never stored. Available scopes are
+payments:create, payments:read, balance:read and provisioning:admin.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		key, entry, err := server.NewCallerKey(callerKeyID, callerKeyScopes...)
+		if err != nil {
+			fmt.Printf("Error generating caller key: %v\n", err)
+			os.Exit(1)
+		}
+
+		keys, err := server.LoadCallerKeys(callerKeysFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Error reading %s: %v\n", callerKeysFile, err)
+			os.Exit(1)
+		}
+		for _, existing := range keys {
+			if existing.ID == callerKeyID {
+				fmt.Printf("A key for caller %s already exists in %s\n", callerKeyID, callerKeysFile)
+				os.Exit(1)
+			}
+		}
+
+		data, err := json.MarshalIndent(append(keys, entry), "", "  ")
+		if err != nil {
+			fmt.Printf("Error encoding caller keys: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(callerKeysFile, data, 0600); err != nil {
+			fmt.Printf("Error writing %s: %v\n", callerKeysFile, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Caller key for %s added to %s\n", callerKeyID, callerKeysFile)
+		fmt.Printf("X-Api-Key: %s\n", key)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(callerKeyCmd)
+
+	callerKeyCmd.Flags().StringVar(&callerKeyID, "id", "", "caller identity recorded on its requests")
+	callerKeyCmd.Flags().StringSliceVar(&callerKeyScopes, "scope", nil, "scope granted to the caller (repeatable)")
+	callerKeyCmd.Flags().StringVar(&callerKeysFile, "file", "caller-keys.json", "caller keys file")
+	callerKeyCmd.MarkFlagRequired("id")
+	callerKeyCmd.MarkFlagRequired("scope")
+}
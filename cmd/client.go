@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/spf13/viper"
+)
+
+// verbose keeps the MoMo client logs of the commands that call the API.
+var verbose bool
+
+// newMomoClient builds a MoMo client from the config file written by `momo-cli provision`.
+// Credentials missing from the file are taken from the environment variables, and fields
+// still empty from the encrypted credential store, the same order as momo.NewClient.
+// Tokens cached by a previous run are reused until they expire.
+func newMomoClient() (*momo.Client, error) {
+	if !verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	var file momo.Config
+	if err := viper.Unmarshal(&file); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+
+	cfg := momo.ConfigFromEnv()
+	if file.Environment != "" {
+		cfg.Environment = file.Environment
+	}
+	if file.BaseURL != "" {
+		cfg.BaseURL = file.BaseURL
+	}
+
+	mergeCredentials(&cfg, file)
+
+	store, err := momo.CredentialStoreFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		if cfg, err = momo.FillConfigFromStore(cfg, store); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Collection.ApiUserID == "" || cfg.Collection.ApiKey == "" {
+		return nil, fmt.Errorf("no collection credentials found, run `momo-cli provision` or set API_USER_ID and API_KEY")
+	}
+	client := momo.NewClientFromConfig(cfg)
+	restoreTokens(client)
+	return client, nil
+}
+
+// mergeCredentials replaces the credentials of each product that src defines.
+func mergeCredentials(dst *momo.Config, src momo.Config) {
+	for _, pair := range []struct{ dst, src *momo.Credentials }{
+		{&dst.Collection, &src.Collection},
+		{&dst.Disbursement, &src.Disbursement},
+		{&dst.Remittance, &src.Remittance},
+	} {
+		if pair.src.ApiUserID != "" {
+			*pair.dst = *pair.src
+		}
+	}
+}
+
+// mustMomoClient is newMomoClient for commands that cannot go on without a client.
+func mustMomoClient() *momo.Client {
+	client, err := newMomoClient()
+	if err != nil {
+		fmt.Printf("Error loading MoMo credentials: %v\n", err)
+		os.Exit(1)
+	}
+	return client
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+var (
+	credsStoreFile   string
+	credsKeyFile     string
+	credsEnvironment string
+	credsProduct     string
+	credsNew         momo.Credentials
+)
+
+// credsCmd represents the creds command
+var credsCmd = &cobra.Command{
+	Use:   "creds",
+	Short: "Manage the encrypted credential store",
+	Long: `Add, list and remove API users and keys in the encrypted credential store.
+
+The store is unlocked with --key-file or with the MOMO_CREDENTIALS_PASSPHRASE
+environment variable.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var credsAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add or replace credentials for an environment and product",
+	Long: `Add or replace credentials for an environment and product.
+
+The API key is never taken from the command line, where it would show up in
+the process list and the shell history. It is prompted for when stdin is a
+terminal, and read from the first line of stdin otherwise:
+
+  momo-cli creds add --subscription-key <key> --api-user-id <id> < api-key.txt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store, product := openCredentialStoreForProduct()
+		apiKey, err := readAPIKey()
+		if err != nil {
+			fmt.Printf("Error reading API key: %v\n", err)
+			os.Exit(1)
+		}
+		credsNew.ApiKey = apiKey
+		if err := store.Put(credsEnvironment, product, credsNew); err != nil {
+			fmt.Printf("Error saving credentials: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Credentials for %s/%s saved to %s\n", credsEnvironment, product, store.Path())
+	},
+}
+
+var credsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored credentials",
+	Run: func(cmd *cobra.Command, args []string) {
+		store := mustOpenCredentialStore()
+		entries, err := store.List()
+		if err != nil {
+			fmt.Printf("Error reading credential store: %v\n", err)
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			fmt.Println("No credentials stored.")
+			return
+		}
+		for _, entry := range entries {
+			fmt.Printf("%-12s %-13s api_user_id=%s api_key=%s subscription_key=%s\n",
+				entry.Environment, entry.Product, entry.Credentials.ApiUserID,
+				mask(entry.Credentials.ApiKey), mask(entry.Credentials.SubscriptionKey))
+		}
+	},
+}
+
+var credsRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove credentials for an environment and product",
+	Run: func(cmd *cobra.Command, args []string) {
+		store, product := openCredentialStoreForProduct()
+		if err := store.Delete(credsEnvironment, product); err != nil {
+			fmt.Printf("Error removing credentials: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Credentials for %s/%s removed\n", credsEnvironment, product)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(credsCmd)
+	credsCmd.AddCommand(credsAddCmd, credsListCmd, credsRemoveCmd)
+
+	credsCmd.PersistentFlags().StringVar(&credsStoreFile, "store", "", "credential store file (default is $HOME/.mtn-momo-api/credentials.enc)")
+	credsCmd.PersistentFlags().StringVar(&credsKeyFile, "key-file", "", "file holding the store key (default is $MOMO_CREDENTIALS_PASSPHRASE)")
+
+	for _, c := range []*cobra.Command{credsAddCmd, credsRemoveCmd} {
+		c.Flags().StringVar(&credsEnvironment, "environment", "sandbox", "target environment")
+		c.Flags().StringVar(&credsProduct, "product", string(momo.ProductCollection), "product: collection, disbursement or remittance")
+	}
+	credsAddCmd.Flags().StringVar(&credsNew.SubscriptionKey, "subscription-key", "", "product subscription key")
+	credsAddCmd.Flags().StringVar(&credsNew.ApiUserID, "api-user-id", "", "API user ID")
+	credsAddCmd.Flags().StringVar(&credsNew.CallbackHost, "callback-host", "", "callback host registered for the API user")
+	credsAddCmd.MarkFlagRequired("subscription-key")
+	credsAddCmd.MarkFlagRequired("api-user-id")
+}
+
+// openCredentialStore opens the encrypted store selected by flags, config or environment.
+func openCredentialStore() (*momo.EncryptedFileStore, error) {
+	path := credsStoreFile
+	if path == "" {
+		path = viper.GetString("momo_credentials_file")
+	}
+	if path == "" {
+		var err error
+		if path, err = momo.DefaultCredentialStorePath(); err != nil {
+			return nil, err
+		}
+	}
+
+	keyFile := credsKeyFile
+	if keyFile == "" {
+		keyFile = viper.GetString("momo_credentials_key_file")
+	}
+	if keyFile != "" {
+		return momo.NewEncryptedFileStoreFromKeyFile(path, keyFile)
+	}
+	return momo.NewEncryptedFileStore(path, viper.GetString("momo_credentials_passphrase"))
+}
+
+func mustOpenCredentialStore() *momo.EncryptedFileStore {
+	store, err := openCredentialStore()
+	if err != nil {
+		fmt.Printf("Error opening credential store: %v\n", err)
+		os.Exit(1)
+	}
+	return store
+}
+
+func openCredentialStoreForProduct() (*momo.EncryptedFileStore, momo.Product) {
+	product, err := momo.ParseProduct(credsProduct)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return mustOpenCredentialStore(), product
+}
+
+// readAPIKey prompts for the API key without echo when stdin is a terminal, and reads
+// the first line of stdin otherwise.
+func readAPIKey() (string, error) {
+	var apiKey string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "API key: ")
+		line, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		apiKey = string(line)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			return "", fmt.Errorf("no API key on stdin: %w", err)
+		}
+		apiKey = line
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return "", errors.New("the API key is empty")
+	}
+	return apiKey, nil
+}
+
+// mask hides all but the last four characters of a secret.
+func mask(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the pay command
+const (
+	exitPaymentFailed  = 2
+	exitPaymentPending = 3
+)
+
+var (
+	payRequest  momo.RequestToPay
+	payMSISDN   string
+	payWait     bool
+	payTimeout  time.Duration
+	payInterval time.Duration
+)
+
+// payCmd represents the pay command
+var payCmd = &cobra.Command{
+	Use:   "pay",
+	Short: "Request a payment from a mobile money account",
+	Long: `Send a request to pay to the payer's MSISDN with the collection credentials of
+the config file, and print the reference ID of the payment.
+
+With --wait, the payment status is polled and displayed until it is final.
+The exit code is 0 when the payment is created (or SUCCESSFUL with --wait),
+1 when the request fails, 2 when the payment is FAILED and 3 when it is still
+PENDING after --timeout.`,
+	Example: `  momo-cli pay --amount 100 --currency EUR --msisdn 46733123453 --external-id order-42 --message "Order 42" --wait`,
+	Run: func(cmd *cobra.Command, args []string) {
+		payRequest.Payer = momo.Party{PartyIdType: momo.PartyIdTypeMSISDN, PartyId: payMSISDN}
+		if payRequest.PayeeNote == "" {
+			payRequest.PayeeNote = payRequest.PayerMessage
+		}
+		if err := payRequest.Validate(); err != nil {
+			fmt.Printf("Invalid payment: %v\n", err)
+			os.Exit(1)
+		}
+
+		client := mustMomoClient()
+		collection := client.Collection()
+		referenceID, err := collection.RequestToPay(payRequest)
+		if err != nil {
+			fmt.Printf("Error requesting payment: %v\n", err)
+			os.Exit(1)
+		}
+		keepTokens(client)
+		fmt.Printf("Payment requested, reference ID: %s\n", referenceID)
+		if !payWait {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), payTimeout)
+		defer cancel()
+		result, err := waitForPayment(ctx, collection.WithContext(ctx), referenceID)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			fmt.Printf("Payment %s is still %s after %s\n", referenceID, momo.StatusPending, payTimeout)
+			os.Exit(exitPaymentPending)
+		case err != nil:
+			fmt.Printf("Error reading payment status: %v\n", err)
+			os.Exit(1)
+		case result.Status == momo.StatusFailed:
+			reason := "no reason given"
+			if result.Reason != nil {
+				reason = result.Reason.Code
+				if result.Reason.Message != "" {
+					reason += ": " + result.Reason.Message
+				}
+			}
+			fmt.Printf("Payment %s FAILED (%s)\n", referenceID, reason)
+			os.Exit(exitPaymentFailed)
+		default:
+			fmt.Printf("Payment %s SUCCESSFUL, financial transaction ID: %s\n", referenceID, result.FinancialTransactionId)
+		}
+	},
+}
+
+// waitForPayment polls the payment every --interval and displays its status until it is
+// final. On a terminal the status line is updated in place.
+func waitForPayment(ctx context.Context, collection *momo.CollectionClient, referenceID string) (*momo.RequestToPayResult, error) {
+	live := isTerminal(os.Stdout)
+	start := time.Now()
+	spinner := `|/-\`
+	last := ""
+
+	ticker := time.NewTicker(payInterval)
+	defer ticker.Stop()
+	for i := 0; ; i++ {
+		result, err := collection.GetPaymentStatus(referenceID)
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			if live {
+				fmt.Println()
+			}
+			return nil, err
+		}
+
+		elapsed := time.Since(start).Truncate(time.Second)
+		switch {
+		case live:
+			fmt.Printf("\r%c %-10s %s ", spinner[i%len(spinner)], result.Status, elapsed)
+		case result.Status != last:
+			fmt.Printf("%s after %s\n", result.Status, elapsed)
+		}
+		last = result.Status
+		if momo.IsFinalStatus(result.Status) {
+			if live {
+				fmt.Println()
+			}
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if live {
+				fmt.Println()
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// isTerminal reports whether f is a character device, such as an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	rootCmd.AddCommand(payCmd)
+
+	payCmd.Flags().StringVar(&payRequest.Amount, "amount", "", "amount to request, for example 100 or 12.50")
+	payCmd.Flags().StringVar(&payRequest.Currency, "currency", "", "ISO 4217 currency code (EUR in the sandbox)")
+	payCmd.Flags().StringVar(&payMSISDN, "msisdn", "", "payer's phone number with country code, without +")
+	payCmd.Flags().StringVar(&payRequest.ExternalId, "external-id", "", "your reference for the payment")
+	payCmd.Flags().StringVar(&payRequest.PayerMessage, "message", "", "message shown to the payer")
+	payCmd.Flags().StringVar(&payRequest.PayeeNote, "note", "", "note recorded for the payee (default is --message)")
+	payCmd.Flags().StringVar(&payRequest.CallbackURL, "callback-url", "", "URL notified by MoMo when the payment is final")
+	payCmd.Flags().BoolVar(&payWait, "wait", false, "wait until the payment is final and display its status")
+	payCmd.Flags().DurationVar(&payTimeout, "timeout", 2*time.Minute, "how long --wait waits for a final status")
+	payCmd.Flags().DurationVar(&payInterval, "interval", 3*time.Second, "interval between two status checks with --wait")
+	for _, name := range []string{"amount", "currency", "msisdn", "external-id"} {
+		payCmd.MarkFlagRequired(name)
+	}
+}
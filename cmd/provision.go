@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	provisionSubscriptionKey string
+	provisionCallbackHost    string
+	provisionProduct         string
+	provisionEnvFile         string
+	provisionToStore         bool
+)
+
+// provisionCmd represents the provision command
+var provisionCmd = &cobra.Command{
+	Use:   "provision",
+	Short: "Provision a sandbox API user and key",
+	Long: `Create a sandbox API user and API key, verify them by requesting a token,
+and write the resulting credentials to the config file or to a .env file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if provisionSubscriptionKey == "" {
+			provisionSubscriptionKey = viper.GetString("subscription_key")
+		}
+
+		product, err := momo.ParseProduct(provisionProduct)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if provisionCallbackHost == "" {
+			provisionCallbackHost = viper.GetString(string(product) + ".callback_host")
+		}
+		if provisionCallbackHost == "" {
+			provisionCallbackHost = viper.GetString("callback_host")
+		}
+
+		fmt.Println("Provisioning sandbox credentials...")
+		creds, err := momo.ProvisionSandboxProduct(context.Background(), product, provisionSubscriptionKey, provisionCallbackHost)
+		if err != nil {
+			fmt.Printf("Error provisioning sandbox: %v\n", err)
+			os.Exit(1)
+		}
+
+		var target string
+		switch {
+		case provisionToStore:
+			target, err = writeCredentialStore(creds)
+		case provisionEnvFile != "":
+			target, err = writeEnvFile(provisionEnvFile, creds)
+		default:
+			target, err = writeViperConfig(creds)
+		}
+		if err != nil {
+			fmt.Printf("Error writing credentials: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("API user %s provisioned for %s, credentials written to %s\n", creds.ApiUserID, creds.Product, target)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(provisionCmd)
+
+	provisionCmd.Flags().StringVar(&provisionSubscriptionKey, "subscription-key", "", "product subscription key (default is $SUBSCRIPTION_KEY)")
+	provisionCmd.Flags().StringVar(&provisionCallbackHost, "callback-host", "", "provider callback host (default is $CALLBACK_HOST)")
+	provisionCmd.Flags().StringVar(&provisionProduct, "product", string(momo.ProductCollection), "product to provision: collection, disbursement or remittance")
+	provisionCmd.Flags().StringVar(&provisionEnvFile, "env-file", "", "write the credentials to this .env file instead of the config file")
+	provisionCmd.Flags().BoolVar(&provisionToStore, "store", false, "write the credentials to the encrypted credential store instead of the config file")
+}
+
+// writeViperConfig stores the credentials, callback host included, under the product key of
+// the config file, where momo.Credentials reads them.
+func writeViperConfig(creds *momo.SandboxCredentials) (string, error) {
+	product := string(creds.Product)
+	viper.Set("environment", creds.Environment)
+	viper.Set(product+".subscription_key", creds.SubscriptionKey)
+	viper.Set(product+".api_user_id", creds.ApiUserID)
+	viper.Set(product+".api_key", creds.ApiKey)
+	viper.Set(product+".callback_host", creds.CallbackHost)
+
+	target := viper.ConfigFileUsed()
+	if target == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		target = filepath.Join(home, ".mtn-momo-api.yaml")
+	}
+	if err := viper.WriteConfigAs(target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// writeCredentialStore saves the credentials in the encrypted credential store.
+func writeCredentialStore(creds *momo.SandboxCredentials) (string, error) {
+	store, err := openCredentialStore()
+	if err != nil {
+		return "", err
+	}
+	if err := store.Put(creds.Environment, creds.Product, creds.Credentials); err != nil {
+		return "", err
+	}
+	return store.Path(), nil
+}
+
+// writeEnvFile merges the credentials into an existing .env file, or creates it.
+func writeEnvFile(path string, creds *momo.SandboxCredentials) (string, error) {
+	env := map[string]string{}
+	if _, err := os.Stat(path); err == nil {
+		if env, err = godotenv.Read(path); err != nil {
+			return "", err
+		}
+	}
+
+	prefix := strings.ToUpper(string(creds.Product)) + "_"
+	env["ENVIRONMENT"] = creds.Environment
+	env["CALLBACK_HOST"] = creds.CallbackHost
+	env[prefix+"SUBSCRIPTION_KEY"] = creds.SubscriptionKey
+	env[prefix+"API_USER_ID"] = creds.ApiUserID
+	env[prefix+"API_KEY"] = creds.ApiKey
+	env[prefix+"CALLBACK_HOST"] = creds.CallbackHost
+	if creds.Product == momo.ProductCollection {
+		env["SUBSCRIPTION_KEY"] = creds.SubscriptionKey
+		env["API_USER_ID"] = creds.ApiUserID
+		env["API_KEY"] = creds.ApiKey
+	}
+
+	if err := godotenv.Write(env, path); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
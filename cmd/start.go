@@ -1,32 +1,107 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
 
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/metrics"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/enzoforreal/mtn-momo-api/server"
+	"github.com/enzoforreal/mtn-momo-api/webhook"
+	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 )
 
+var (
+	startAddr     string
+	startGRPCAddr string
+	startEnvFile  string
+
+	startAllowAnonymous bool
+)
+
 // startCmd represents the start command
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start the server",
-	Long:  `Start the momo API server.`,
+	Long: `Start the momo API gateway server. It stops gracefully on SIGINT or SIGTERM.
+
+With --grpc-addr or $GRPC_ADDR, the gRPC service is started alongside the gateway and
+shares its payment status streams.
+
+Callers must present a caller key or a JWT. Without $GATEWAY_CALLER_KEYS_FILE or
+$GATEWAY_JWT_SECRET every request is rejected, unless --allow-anonymous (or
+$GATEWAY_ALLOW_ANONYMOUS) gives every caller all scopes, for local development only.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := godotenv.Load(startEnvFile); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Error loading %s: %v\n", startEnvFile, err)
+			os.Exit(1)
+		}
+
+		cfg, err := server.ConfigFromEnv()
+		if err != nil {
+			fmt.Printf("Error loading gateway configuration: %v\n", err)
+			os.Exit(1)
+		}
+		if startAddr != "" {
+			cfg.Addr = startAddr
+		}
+
+		if startGRPCAddr != "" {
+			cfg.GRPCAddr = startGRPCAddr
+		}
+		if startAllowAnonymous {
+			cfg.AllowAnonymous = true
+		}
+
 		fmt.Println("Starting the server...")
-		exampleDir, _ := filepath.Abs(filepath.Join(filepath.Dir(os.Args[0]), "example"))
-		execCmd := exec.Command("go", "run", "main.go")
-		execCmd.Dir = exampleDir
-		execCmd.Stdout = os.Stdout
-		execCmd.Stderr = os.Stderr
-		if err := execCmd.Run(); err != nil {
+		client, err := momo.NewClient()
+		if err != nil {
+			fmt.Printf("Error loading MoMo credentials: %v\n", err)
+			os.Exit(1)
+		}
+		// Resolve the shared state once: the gateway and the gRPC service must enforce the
+		// same limits and idempotency keys, and /metrics must cover the client of both
+		if cfg.Metrics == nil {
+			cfg.Metrics = metrics.New()
+		}
+		if cfg.RateLimits.Store == nil {
+			cfg.RateLimits.Store = server.NewMemoryLimiterStore()
+		}
+		if cfg.Idempotency == nil {
+			cfg.Idempotency = server.NewMemoryIdempotencyStore()
+		}
+		if cfg.GRPCAddr != "" {
+			// Share the status hub so gateway callbacks also reach WatchPayment streams
+			cfg.StatusHub = httpapi.NewStatusHub(client)
+			if cfg.StatusPollInterval > 0 {
+				cfg.StatusHub.PollInterval = cfg.StatusPollInterval
+			}
+			grpcServer := server.NewGRPC(cfg, client)
+			go func() {
+				if err := server.ListenAndServeGRPC(context.Background(), cfg, grpcServer); err != nil {
+					fmt.Printf("Error starting gRPC service: %v\n", err)
+					os.Exit(1)
+				}
+			}()
+		}
+		// Keep the relay so ListenAndServe waits for pending webhook deliveries
+		cfg.Relay = webhook.NewRelay()
+		handler := server.New(cfg, client)
+		if err := server.ListenAndServe(context.Background(), cfg, handler); err != nil {
 			fmt.Printf("Error starting server: %v\n", err)
+			os.Exit(1)
 		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(startCmd)
+
+	startCmd.Flags().StringVar(&startAddr, "addr", "", "address to listen on (default is $SERVER_ADDR or :8080)")
+	startCmd.Flags().StringVar(&startGRPCAddr, "grpc-addr", "", "address of the gRPC service (default is $GRPC_ADDR, disabled when empty)")
+	startCmd.Flags().StringVar(&startEnvFile, "env-file", ".env", "environment file to load before starting")
+	startCmd.Flags().BoolVar(&startAllowAnonymous, "allow-anonymous", false, "accept unauthenticated callers when no caller keys or JWT secret are configured (development only)")
 }
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status <reference-id>",
+	Short: "Show the status of a payment",
+	Long: `Read a request to pay from the collection API and print all the fields of the
+result: status, amount, payer, messages, financial transaction ID and, for a
+failed payment, the reason given by MoMo.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := mustMomoClient()
+		result, err := client.Collection().GetPaymentStatus(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading payment status: %v\n", err)
+			os.Exit(1)
+		}
+		keepTokens(client)
+		if result.ReferenceId == "" {
+			result.ReferenceId = args[0]
+		}
+
+		if outputJSON {
+			printJSON(result)
+			return
+		}
+		reason := ""
+		if result.Reason != nil {
+			reason = result.Reason.Code
+			if result.Reason.Message != "" {
+				reason += ": " + result.Reason.Message
+			}
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, field := range [][2]string{
+			{"Reference ID", result.ReferenceId},
+			{"Status", result.Status},
+			{"Amount", result.Amount},
+			{"Currency", result.Currency},
+			{"Financial transaction ID", result.FinancialTransactionId},
+			{"External ID", result.ExternalId},
+			{"Payer", result.Payer.PartyIdType + " " + result.Payer.PartyId},
+			{"Payer message", result.PayerMessage},
+			{"Payee note", result.PayeeNote},
+			{"Reason", reason},
+		} {
+			value := field[1]
+			if value == "" || value == " " {
+				value = "-"
+			}
+			fmt.Fprintf(w, "%s:\t%s\n", field[0], value)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().BoolVar(&outputJSON, "json", false, "print the result as JSON")
+}
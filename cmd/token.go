@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenProduct string
+	tokenRefresh bool
+	tokenReveal  bool
+)
+
+// tokenInfo describes the access token of a product.
+type tokenInfo struct {
+	Product     momo.Product `json:"product"`
+	Environment string       `json:"environment"`
+	TokenType   string       `json:"token_type"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	ExpiresIn   int          `json:"expires_in"`
+	Cached      bool         `json:"cached"`
+	AccessToken string       `json:"access_token"`
+}
+
+// tokenCmd represents the token command
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Get an access token and show its type and lifetime",
+	Long: `Get an access token for a product and cache it until it expires, so that later
+commands reuse it. The token is read from the cache when it is still valid,
+unless --refresh is given.
+
+The token is masked unless --reveal is given. The cache file is
+$HOME/.mtn-momo-api/tokens.json, or $MOMO_TOKEN_CACHE_FILE.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		product, err := momo.ParseProduct(tokenProduct)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		client := mustMomoClient()
+		var p *momo.ProductClient
+		for _, candidate := range productClients(client) {
+			if candidate.Product == product {
+				p = candidate
+			}
+		}
+		if p == nil {
+			fmt.Fprintf(os.Stderr, "No credentials found for %s\n", product)
+			os.Exit(1)
+		}
+
+		if tokenRefresh {
+			p.InvalidateToken()
+		}
+		cached, _ := p.CachedToken()
+		if _, err := p.Token(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting access token: %v\n", err)
+			os.Exit(1)
+		}
+		keepTokens(client)
+
+		token, expiresAt := p.CachedToken()
+		info := tokenInfo{
+			Product:     product,
+			Environment: client.Environment,
+			TokenType:   token.TokenType,
+			ExpiresAt:   expiresAt.UTC().Truncate(time.Second),
+			ExpiresIn:   int(time.Until(expiresAt).Seconds()),
+			Cached:      cached != nil && cached.AccessToken == token.AccessToken,
+			AccessToken: token.AccessToken,
+		}
+		if !tokenReveal {
+			info.AccessToken = mask(info.AccessToken)
+		}
+
+		if outputJSON {
+			printJSON(info)
+			return
+		}
+		source := "new token from MoMo"
+		if info.Cached {
+			source = "cache"
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Product:\t%s\n", info.Product)
+		fmt.Fprintf(w, "Token type:\t%s\n", info.TokenType)
+		fmt.Fprintf(w, "Expires at:\t%s\n", info.ExpiresAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "Remaining:\t%s\n", (time.Duration(info.ExpiresIn) * time.Second).String())
+		fmt.Fprintf(w, "Source:\t%s\n", source)
+		fmt.Fprintf(w, "Access token:\t%s\n", info.AccessToken)
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+
+	tokenCmd.Flags().StringVar(&tokenProduct, "product", string(momo.ProductCollection), "product: collection, disbursement or remittance")
+	tokenCmd.Flags().BoolVar(&tokenRefresh, "refresh", false, "request a new token even if the cached one is still valid")
+	tokenCmd.Flags().BoolVar(&tokenReveal, "reveal", false, "print the full access token")
+	tokenCmd.Flags().BoolVar(&outputJSON, "json", false, "print the token information as JSON")
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/spf13/viper"
+)
+
+// cachedToken is an access token kept on disk between two runs of the CLI.
+type cachedToken struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// productClients returns the client of each product that has credentials.
+func productClients(client *momo.Client) []*momo.ProductClient {
+	var products []*momo.ProductClient
+	for _, p := range []*momo.ProductClient{
+		client.Collection().ProductClient,
+		client.Disbursement().ProductClient,
+		client.Remittance().ProductClient,
+	} {
+		if p.Credentials.ApiUserID != "" {
+			products = append(products, p)
+		}
+	}
+	return products
+}
+
+// tokenCachePath returns $MOMO_TOKEN_CACHE_FILE, or $HOME/.mtn-momo-api/tokens.json.
+func tokenCachePath() (string, error) {
+	if path := viper.GetString("momo_token_cache_file"); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".mtn-momo-api", "tokens.json"), nil
+}
+
+// tokenCacheKey identifies the tokens of an API user in an environment and product.
+func tokenCacheKey(client *momo.Client, p *momo.ProductClient) string {
+	return client.Environment + "/" + string(p.Product) + "/" + p.Credentials.ApiUserID
+}
+
+func readTokenCache() (map[string]cachedToken, error) {
+	tokens := map[string]cachedToken{}
+	path, err := tokenCachePath()
+	if err != nil {
+		return tokens, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return tokens, err
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return map[string]cachedToken{}, err
+	}
+	return tokens, nil
+}
+
+// restoreTokens puts the unexpired tokens of the cache file back in the client.
+// An unreadable cache only means that new tokens are requested.
+func restoreTokens(client *momo.Client) {
+	tokens, _ := readTokenCache()
+	for _, p := range productClients(client) {
+		if cached, ok := tokens[tokenCacheKey(client, p)]; ok {
+			p.RestoreToken(&momo.AuthToken{AccessToken: cached.AccessToken, TokenType: cached.TokenType}, cached.ExpiresAt)
+		}
+	}
+}
+
+// saveTokens writes the client's cached tokens to the cache file, readable by the
+// current user only, and drops the expired ones.
+func saveTokens(client *momo.Client) error {
+	tokens, _ := readTokenCache()
+	for key, cached := range tokens {
+		if !time.Now().Before(cached.ExpiresAt) {
+			delete(tokens, key)
+		}
+	}
+	for _, p := range productClients(client) {
+		if token, expiresAt := p.CachedToken(); token != nil {
+			tokens[tokenCacheKey(client, p)] = cachedToken{AccessToken: token.AccessToken, TokenType: token.TokenType, ExpiresAt: expiresAt}
+		}
+	}
+
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	path, err := tokenCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// keepTokens saves the client's tokens for the next run. A failure only costs a new token
+// next time, so it is reported as a warning.
+func keepTokens(client *momo.Client) {
+	if err := saveTokens(client); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not cache the access tokens: %v\n", err)
+	}
+}
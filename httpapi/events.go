@@ -0,0 +1,141 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/gorilla/websocket"
+)
+
+// Chemins des flux de statut d'un paiement
+const (
+	PaymentEventsPath = "/payments/{" + ReferenceIDParam + "}/events"
+	PaymentSocketPath = "/payments/{" + ReferenceIDParam + "}/ws"
+)
+
+// Intervalle des messages de maintien de connexion
+const streamHeartbeat = 15 * time.Second
+
+// Délai accordé à l'écriture d'un message WebSocket
+const socketWriteTimeout = 10 * time.Second
+
+// PaymentEvents diffuse le statut d'un paiement en Server-Sent Events : un événement
+// "status" pour le statut actuel puis pour chaque changement, jusqu'au statut final.
+// L'identifiant de chaque événement est le statut ; quand EventSource se reconnecte après
+// un statut final, la réponse 204 lui indique de ne plus se reconnecter.
+func (h *Handlers) PaymentEvents(w http.ResponseWriter, r *http.Request) {
+	if momo.IsFinalStatus(r.Header.Get("Last-Event-ID")) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteProblem(w, r, Problem{Status: http.StatusInternalServerError, Detail: "streaming is not supported"})
+		return
+	}
+
+	referenceID, ok := referenceIDParam(w, r)
+	if !ok {
+		return
+	}
+	updates, err := h.hub.Watch(r.Context(), referenceID)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(update)
+			if err != nil {
+				log.Printf("Error encoding status update: %v", err)
+				return
+			}
+			fmt.Fprintf(w, "id: %s\nevent: status\ndata: %s\n\n", update.Status, data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// PaymentSocket diffuse les mêmes mises à jour que PaymentEvents sur une WebSocket, un
+// message JSON par mise à jour. La connexion est fermée normalement après le statut final.
+func (h *Handlers) PaymentSocket(w http.ResponseWriter, r *http.Request) {
+	referenceID, ok := referenceIDParam(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	updates, err := h.hub.Watch(ctx, referenceID)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: h.CheckOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade a déjà répondu au client
+		log.Printf("Error upgrading to WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// Les messages du client sont ignorés ; la lecture détecte la fermeture de la connexion
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				if ctx.Err() != nil {
+					return
+				}
+				message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "final status")
+				conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(socketWriteTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+			if err := conn.WriteJSON(update); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteTimeout)); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/gorilla/websocket"
+)
+
+// newStatusGateway sert les handlers devant une fausse API MoMo dont le statut de
+// paiement est modifiable. La référence 00000000-0000-4000-8000-000000000404 est inconnue de MoMo.
+func newStatusGateway(t *testing.T) (*httptest.Server, *Handlers, func(status string)) {
+	var mu sync.Mutex
+	status := momo.StatusPending
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/collection/token/":
+			json.NewEncoder(w).Encode(momo.AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+		case r.URL.Path == "/collection/v2_0/payment/00000000-0000-4000-8000-000000000404":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code": "RESOURCE_NOT_FOUND", "message": "Requested resource was not found."}`))
+		default:
+			mu.Lock()
+			defer mu.Unlock()
+			json.NewEncoder(w).Encode(momo.RequestToPayResult{Status: status})
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	client := momo.NewClientFromConfig(momo.Config{BaseURL: upstream.URL})
+	handlers := New(client, nil)
+	gateway := httptest.NewServer(handlers.Mux())
+	t.Cleanup(gateway.Close)
+
+	return gateway, handlers, func(next string) {
+		mu.Lock()
+		defer mu.Unlock()
+		status = next
+	}
+}
+
+// readEvents lit les événements du flux jusqu'à sa fermeture.
+func readEvents(t *testing.T, resp *http.Response, received chan<- PaymentUpdate) {
+	defer close(received)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var update PaymentUpdate
+		if err := json.Unmarshal([]byte(data), &update); err != nil {
+			t.Errorf("invalid event data %q", data)
+			return
+		}
+		received <- update
+	}
+}
+
+func nextUpdate(t *testing.T, received <-chan PaymentUpdate) PaymentUpdate {
+	t.Helper()
+	select {
+	case update, ok := <-received:
+		if !ok {
+			t.Fatal("the stream closed early")
+		}
+		return update
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a status update")
+	}
+	return PaymentUpdate{}
+}
+
+// expectPayment enregistre une demande de paiement en attente, comme après RequestToPay.
+func expectPayment(t *testing.T, handlers *Handlers, referenceID string) {
+	t.Helper()
+	pending := momo.PendingRequest{ReferenceID: referenceID, Type: momo.CallbackRequestToPay, Product: momo.ProductCollection, Currency: "EUR"}
+	if err := handlers.client.Pending.Add(context.Background(), pending); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sendCallback(t *testing.T, gateway *httptest.Server, referenceID, status string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPut, gateway.URL+"/callbacks/requesttopay/"+referenceID, strings.NewReader(`{"status": "`+status+`"}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestPaymentEventsFromCallback(t *testing.T) {
+	gateway, handlers, setStatus := newStatusGateway(t)
+	expectPayment(t, handlers, "00000000-0000-4000-8000-000000000001")
+
+	resp, err := http.Get(gateway.URL + "/payments/00000000-0000-4000-8000-000000000001/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	received := make(chan PaymentUpdate)
+	go readEvents(t, resp, received)
+	if update := nextUpdate(t, received); update.Status != momo.StatusPending || update.Source != SourcePoll {
+		t.Fatalf("expected the current PENDING status first, got %+v", update)
+	}
+
+	setStatus(momo.StatusSuccessful)
+	sendCallback(t, gateway, "00000000-0000-4000-8000-000000000001", momo.StatusSuccessful)
+	if update := nextUpdate(t, received); update.Status != momo.StatusSuccessful || update.Source != SourceCallback {
+		t.Fatalf("expected SUCCESSFUL from the callback, got %+v", update)
+	}
+	if _, open := <-received; open {
+		t.Fatal("expected the stream to close after the final status")
+	}
+}
+
+func TestForgedCallbackIsNotPublished(t *testing.T) {
+	gateway, handlers, _ := newStatusGateway(t)
+	expectPayment(t, handlers, "00000000-0000-4000-8000-000000000005")
+
+	resp, err := http.Get(gateway.URL + "/payments/00000000-0000-4000-8000-000000000005/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	received := make(chan PaymentUpdate)
+	go readEvents(t, resp, received)
+	nextUpdate(t, received)
+
+	// MoMo déclare toujours le paiement PENDING : le callback SUCCESSFUL est un faux
+	sendCallback(t, gateway, "00000000-0000-4000-8000-000000000005", momo.StatusSuccessful)
+	select {
+	case update := <-received:
+		t.Fatalf("expected the forged callback to be dropped, got %+v", update)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSlowSubscriberGetsFinalStatus(t *testing.T) {
+	_, handlers, _ := newStatusGateway(t)
+	hub := handlers.Hub()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := hub.Watch(ctx, "00000000-0000-4000-8000-000000000006")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// L'abonné ne lit rien tant que son tampon n'est pas saturé
+	for i := 0; i < 2*updateBufferSize; i++ {
+		status := "ONGOING"
+		if i%2 == 1 {
+			status = momo.StatusPending
+		}
+		hub.Publish(PaymentUpdate{ReferenceID: "00000000-0000-4000-8000-000000000006", Status: status})
+	}
+	hub.Publish(PaymentUpdate{ReferenceID: "00000000-0000-4000-8000-000000000006", Status: momo.StatusSuccessful})
+
+	var last PaymentUpdate
+	for update := range updates {
+		last = update
+	}
+	if last.Status != momo.StatusSuccessful {
+		t.Fatalf("expected the final status before the channel closed, got %+v", last)
+	}
+}
+
+func TestPaymentEventsFromPolling(t *testing.T) {
+	gateway, handlers, setStatus := newStatusGateway(t)
+	handlers.Hub().PollInterval = 10 * time.Millisecond
+
+	resp, err := http.Get(gateway.URL + "/payments/00000000-0000-4000-8000-000000000002/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	received := make(chan PaymentUpdate)
+	go readEvents(t, resp, received)
+	nextUpdate(t, received)
+
+	setStatus(momo.StatusFailed)
+	if update := nextUpdate(t, received); update.Status != momo.StatusFailed || update.Source != SourcePoll {
+		t.Fatalf("expected FAILED from polling, got %+v", update)
+	}
+}
+
+func TestPaymentEventsErrors(t *testing.T) {
+	gateway, _, _ := newStatusGateway(t)
+
+	resp, err := http.Get(gateway.URL + "/payments/00000000-0000-4000-8000-000000000404/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get("Content-Type") != ProblemContentType {
+		t.Fatalf("expected a 404 problem for an unknown reference, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/payments/00000000-0000-4000-8000-000000000003/events", nil)
+	req.Header.Set("Last-Event-ID", momo.StatusSuccessful)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204 after a final status, got %d", resp.StatusCode)
+	}
+}
+
+func TestPaymentSocket(t *testing.T) {
+	gateway, handlers, setStatus := newStatusGateway(t)
+	expectPayment(t, handlers, "00000000-0000-4000-8000-000000000004")
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(gateway.URL, "http")+"/payments/00000000-0000-4000-8000-000000000004/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var update PaymentUpdate
+	if err := conn.ReadJSON(&update); err != nil || update.Status != momo.StatusPending {
+		t.Fatalf("expected the current PENDING status first, got %+v, %v", update, err)
+	}
+
+	setStatus(momo.StatusSuccessful)
+	sendCallback(t, gateway, "00000000-0000-4000-8000-000000000004", momo.StatusSuccessful)
+	if err := conn.ReadJSON(&update); err != nil || update.Status != momo.StatusSuccessful {
+		t.Fatalf("expected SUCCESSFUL from the callback, got %+v, %v", update, err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("expected a normal closure after the final status, got %v", err)
+	}
+}
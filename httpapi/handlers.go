@@ -0,0 +1,189 @@
+// Package httpapi expose les opérations MoMo sous forme de handlers net/http, sans
+// dépendre d'un framework. Les adaptateurs gin, chi et echo se trouvent dans adapters/.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+)
+
+// ReferenceIDParam est le nom du paramètre de chemin portant l'identifiant de référence.
+// Les handlers le lisent avec r.PathValue ; les adaptateurs le renseignent depuis leur routeur.
+const ReferenceIDParam = "reference_id"
+
+// Taille maximale acceptée pour le corps d'une demande
+const maxRequestBodySize = 1 << 20
+
+// Chemins des routes exposées par Handlers
+const (
+	RequestToPayPath  = "/request-to-pay"
+	PaymentStatusPath = "/payment-status/{" + ReferenceIDParam + "}"
+	BalancePath       = "/get-account-balance"
+	CallbacksPath     = "/callbacks/"
+)
+
+// Handlers regroupe les handlers net/http des paiements, du statut, du solde, des callbacks
+// et des flux de statut.
+type Handlers struct {
+	// CheckOrigin accepte ou refuse l'origine d'une connexion WebSocket. Par défaut,
+	// seule la même origine que la requête est acceptée.
+	CheckOrigin func(r *http.Request) bool
+
+	client     *momo.Client
+	onCallback momo.CallbackFunc
+	verifier   *momo.CallbackVerifier
+	hub        *StatusHub
+}
+
+// New crée les handlers autour d'un client MoMo. onCallback reçoit les notifications
+// reçues sur /callbacks/ une fois vérifiées ; s'il est nil, elles sont journalisées.
+// Les notifications sont vérifiées par un momo.CallbackVerifier : si le client n'a pas
+// de PendingStore, un MemoryPendingStore lui est attribué.
+func New(client *momo.Client, onCallback momo.CallbackFunc) *Handlers {
+	if onCallback == nil {
+		onCallback = LogCallback
+	}
+	h := &Handlers{client: client, onCallback: onCallback, hub: NewStatusHub(client)}
+	h.verifier = momo.NewCallbackVerifier(client, h.handleCallback)
+	return h
+}
+
+// Hub renvoie le StatusHub qui alimente les flux de statut.
+func (h *Handlers) Hub() *StatusHub {
+	return h.hub
+}
+
+// UseHub remplace le StatusHub des flux de statut, par exemple pour le partager avec un
+// autre service.
+func (h *Handlers) UseHub(hub *StatusHub) {
+	h.hub = hub
+}
+
+// Mux enregistre les handlers sur un http.ServeMux, derrière Correlate.
+func (h *Handlers) Mux() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+RequestToPayPath, h.RequestToPay)
+	mux.HandleFunc("GET "+PaymentStatusPath, h.PaymentStatus)
+	mux.HandleFunc("GET "+BalancePath, h.Balance)
+	mux.HandleFunc("GET "+PaymentEventsPath, h.PaymentEvents)
+	mux.HandleFunc("GET "+PaymentSocketPath, h.PaymentSocket)
+	mux.Handle("PUT "+CallbacksPath, h.Callbacks())
+	mux.Handle("POST "+CallbacksPath, h.Callbacks())
+	return Correlate(mux)
+}
+
+// RequestToPay valide la demande, l'envoie à MoMo et répond 202 avec sa référence.
+// L'en-tête X-Callback-Url est transmis à MoMo.
+func (h *Handlers) RequestToPay(w http.ResponseWriter, r *http.Request) {
+	var req momo.RequestToPay
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		WriteProblem(w, r, Problem{Status: http.StatusBadRequest, Detail: err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	req.CallbackURL = r.Header.Get("X-Callback-Url")
+	req.CallerID = CallerID(r.Context())
+
+	referenceID, err := h.client.Collection().WithContext(r.Context()).RequestToPay(req)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	log.Printf("Payment request %s created by caller %s", referenceID, req.CallerID)
+	writeJSON(w, http.StatusAccepted, map[string]string{"message": "Payment request created successfully", "reference_id": referenceID})
+}
+
+// PaymentStatus renvoie le statut du paiement désigné par le paramètre reference_id.
+func (h *Handlers) PaymentStatus(w http.ResponseWriter, r *http.Request) {
+	referenceID, ok := referenceIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	paymentStatus, err := h.client.Collection().WithContext(r.Context()).GetPaymentStatus(referenceID)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	log.Printf("Payment status for reference ID %s retrieved successfully", referenceID)
+	writeJSON(w, http.StatusOK, paymentStatus)
+}
+
+// Balance renvoie le solde du compte de collection.
+func (h *Handlers) Balance(w http.ResponseWriter, r *http.Request) {
+	balance, err := h.client.Collection().WithContext(r.Context()).GetAccountBalance()
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	log.Println("Account balance retrieved successfully")
+	writeJSON(w, http.StatusOK, map[string]*momo.Balance{"balance": balance})
+}
+
+// Callbacks reçoit les notifications PUT/POST de MoMo. Seules les notifications d'une
+// opération en attente dont le statut est confirmé par MoMo sont traitées par onCallback,
+// puis diffusées aux flux de statut ; les autres ne sont pas diffusées.
+func (h *Handlers) Callbacks() http.Handler {
+	handler := momo.NewCallbackHandler(h.verifier.Handle)
+	handler.TracerProvider = h.client.TracerProvider
+	return handler
+}
+
+// handleCallback traite un callback vérifié. Un statut final est compté dans
+// client.Metrics, une seule fois par référence puisque le vérificateur retire ensuite
+// l'opération en attente.
+func (h *Handlers) handleCallback(ctx context.Context, event momo.CallbackEvent) error {
+	if err := h.onCallback(ctx, event); err != nil {
+		return err
+	}
+	h.hub.PublishCallback(event)
+	if momo.IsFinalStatus(event.Status()) && h.client.Metrics != nil {
+		pending, ok, err := h.client.Pending.Get(ctx, event.Meta().ReferenceID)
+		if err != nil {
+			log.Printf("Error reading pending request %s: %v", event.Meta().ReferenceID, err)
+		} else if ok {
+			h.client.Metrics.ObserveFinalStatus(pending.Product, event.Status(), pending.Currency)
+		}
+	}
+	return nil
+}
+
+// referenceIDParam lit le paramètre reference_id et vérifie qu'il s'agit d'un UUID, pour
+// qu'il ne puisse pas désigner un autre chemin de l'API MoMo. Sinon, un problème 400 est écrit.
+func referenceIDParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	referenceID := r.PathValue(ReferenceIDParam)
+	if referenceID == "" {
+		WriteProblem(w, r, Problem{Status: http.StatusBadRequest, Detail: "Reference ID is required"})
+		return "", false
+	}
+	if err := momo.ValidateReferenceID(referenceID); err != nil {
+		WriteProblem(w, r, Problem{Status: http.StatusBadRequest, Detail: err.Error()})
+		return "", false
+	}
+	return referenceID, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// LogCallback journalise une notification MoMo. C'est le traitement par défaut des callbacks.
+func LogCallback(ctx context.Context, event momo.CallbackEvent) error {
+	meta := event.Meta()
+	log.Printf("Callback %s for reference ID %s: status %s", meta.Type, meta.ReferenceID, event.Status())
+	return nil
+}
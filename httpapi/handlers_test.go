@@ -0,0 +1,27 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/internal/momotest"
+)
+
+func TestMux(t *testing.T) {
+	momotest.CheckRoutes(t, New(momotest.NewClient(t), nil).Mux())
+}
+
+func TestReferenceIDMustBeUUID(t *testing.T) {
+	mux := New(momotest.NewClient(t), nil).Mux()
+
+	// ServeMux décode %2F : sans vérification, l'identifiant désignerait un autre chemin de MoMo
+	traversal := "..%2F..%2Fv1_0%2Faccountholder%2Fmsisdn%2F123%2Fbasicuserinfo"
+	for _, path := range []string{"/payment-status/" + traversal, "/payments/" + traversal + "/events", "/payments/" + traversal + "/ws"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != ProblemContentType {
+			t.Errorf("%s: expected a 400 problem, got %d", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/google/uuid"
+)
+
+// CorrelationIDHeader porte l'identifiant de corrélation d'une requête
+const CorrelationIDHeader = "X-Correlation-Id"
+
+// Type de contenu des réponses d'erreur (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Structure pour une réponse d'erreur au format RFC 7807
+type Problem struct {
+	Type          string            `json:"type"`
+	Title         string            `json:"title"`
+	Status        int               `json:"status"`
+	Detail        string            `json:"detail,omitempty"`
+	Instance      string            `json:"instance,omitempty"`
+	Code          string            `json:"code,omitempty"`
+	CorrelationID string            `json:"correlationId,omitempty"`
+	Fields        []momo.FieldError `json:"fields,omitempty"`
+	// ReferenceID est la référence d'une opération dont l'issue est inconnue : elle a pu
+	// être acceptée par MoMo, et son statut doit être lu avant tout nouvel essai.
+	ReferenceID string `json:"referenceId,omitempty"`
+}
+
+type contextKey int
+
+const (
+	correlationIDKey contextKey = iota
+	callerIDKey
+)
+
+// ContextWithCorrelationID enregistre l'identifiant de corrélation dans le contexte.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// CorrelationID renvoie l'identifiant de corrélation enregistré dans le contexte.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// ContextWithCallerID enregistre l'identité de l'appelant, reportée sur chaque paiement.
+func ContextWithCallerID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, callerIDKey, id)
+}
+
+// CallerID renvoie l'identité de l'appelant enregistrée dans le contexte.
+func CallerID(ctx context.Context) string {
+	id, _ := ctx.Value(callerIDKey).(string)
+	return id
+}
+
+// NewCorrelationID reprend l'en-tête X-Correlation-Id de la requête, ou en génère un.
+func NewCorrelationID(r *http.Request) string {
+	id := r.Header.Get(CorrelationIDHeader)
+	if id == "" || len(id) > 128 {
+		id = uuid.New().String()
+	}
+	return id
+}
+
+// Correlate attribue un identifiant de corrélation à chaque requête et le renvoie
+// dans l'en-tête X-Correlation-Id de la réponse.
+func Correlate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, WithCorrelationID(w, r))
+	})
+}
+
+// WithCorrelationID renvoie r avec un identifiant de corrélation, en le créant s'il
+// n'en a pas encore, et le reporte dans l'en-tête de la réponse.
+func WithCorrelationID(w http.ResponseWriter, r *http.Request) *http.Request {
+	if CorrelationID(r.Context()) != "" {
+		return r
+	}
+	id := NewCorrelationID(r)
+	w.Header().Set(CorrelationIDHeader, id)
+	return r.WithContext(ContextWithCorrelationID(r.Context(), id))
+}
+
+// WriteProblem écrit une réponse application/problem+json.
+func WriteProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Instance = r.URL.Path
+	p.CorrelationID = CorrelationID(r.Context())
+
+	log.Printf("Error [%s] %d %s: %s", p.CorrelationID, p.Status, p.Code, p.Detail)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// WriteError traduit une erreur du client MoMo en réponse problem+json, selon ErrorProblem.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	WriteProblem(w, r, ErrorProblem(err))
+}
+
+// ErrorProblem traduit une erreur du client MoMo en Problem :
+//   - erreurs de validation en 422, URL de callback invalide en 400 ;
+//   - payeur ou ressource introuvable en 404, référence en double en 409,
+//     requête refusée par MoMo en 400 ;
+//   - MoMo indisponible ou injoignable en 503, autre erreur de MoMo en 502.
+//
+// Pour une opération dont l'issue est inconnue, le Problem porte sa référence.
+func ErrorProblem(err error) Problem {
+	p := errorProblem(err)
+	var submitErr *momo.SubmitError
+	if errors.As(err, &submitErr) {
+		p.ReferenceID = submitErr.ReferenceID
+	}
+	return p
+}
+
+func errorProblem(err error) Problem {
+	var invalid *momo.ValidationError
+	if errors.As(err, &invalid) {
+		return Problem{Status: http.StatusUnprocessableEntity, Detail: momo.ErrValidation.Error(), Fields: invalid.Fields}
+	}
+	if errors.Is(err, momo.ErrInvalidCallbackURL) || errors.Is(err, momo.ErrCallbackHostMismatch) || errors.Is(err, momo.ErrInvalidCallbackHost) {
+		return Problem{Status: http.StatusBadRequest, Detail: err.Error()}
+	}
+
+	var apiErr *momo.APIError
+	if errors.As(err, &apiErr) {
+		detail := apiErr.Message
+		if detail == "" {
+			detail = "failed to " + apiErr.Operation
+		}
+		return Problem{Status: UpstreamStatus(apiErr), Detail: detail, Code: apiErr.Code}
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return Problem{Status: http.StatusServiceUnavailable, Detail: "MoMo API is unreachable"}
+	}
+
+	return Problem{Status: http.StatusInternalServerError, Detail: err.Error()}
+}
+
+// UpstreamStatus choisit le statut renvoyé par la passerelle pour une erreur de MoMo.
+func UpstreamStatus(err *momo.APIError) int {
+	switch err.Code {
+	case momo.CodePayerNotFound, momo.CodePayeeNotFound, momo.CodeResourceNotFound:
+		return http.StatusNotFound
+	case momo.CodeResourceAlreadyExists:
+		return http.StatusConflict
+	case momo.CodeServiceUnavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	switch {
+	case err.StatusCode == http.StatusNotFound:
+		return http.StatusNotFound
+	case err.StatusCode == http.StatusConflict:
+		return http.StatusConflict
+	case err.StatusCode == http.StatusServiceUnavailable:
+		return http.StatusServiceUnavailable
+	case err.StatusCode == http.StatusUnauthorized || err.StatusCode == http.StatusForbidden:
+		// Les identifiants de la passerelle sont refusés : ce n'est pas une erreur de l'appelant
+		return http.StatusBadGateway
+	case err.StatusCode >= 400 && err.StatusCode < 500:
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
@@ -0,0 +1,31 @@
+package httpapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+)
+
+func TestUpstreamStatus(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		code       string
+		want       int
+	}{
+		{http.StatusNotFound, momo.CodePayerNotFound, http.StatusNotFound},
+		{http.StatusInternalServerError, momo.CodePayerNotFound, http.StatusNotFound},
+		{http.StatusConflict, momo.CodeResourceAlreadyExists, http.StatusConflict},
+		{http.StatusBadRequest, "INVALID_CURRENCY", http.StatusBadRequest},
+		{http.StatusUnauthorized, "", http.StatusBadGateway},
+		{http.StatusInternalServerError, momo.CodeInternalProcessing, http.StatusBadGateway},
+		{http.StatusServiceUnavailable, "", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		err := &momo.APIError{Operation: "request to pay", StatusCode: tt.statusCode, Code: tt.code}
+		if got := UpstreamStatus(err); got != tt.want {
+			t.Errorf("upstream %d %s: expected %d, got %d", tt.statusCode, tt.code, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package httpapi
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+)
+
+// Origine d'une mise à jour de statut
+const (
+	SourceCallback = "callback"
+	SourcePoll     = "poll"
+)
+
+// Intervalle par défaut entre deux interrogations de MoMo pour un paiement suivi
+const DefaultStatusPollInterval = 5 * time.Second
+
+// Délai accordé à chaque interrogation de MoMo
+const statusPollTimeout = 30 * time.Second
+
+// Nombre de mises à jour en attente pour un abonné
+const updateBufferSize = 8
+
+// Structure pour une mise à jour du statut d'un paiement
+type PaymentUpdate struct {
+	ReferenceID string                   `json:"referenceId"`
+	Status      string                   `json:"status"`
+	Source      string                   `json:"source"`
+	Result      *momo.RequestToPayResult `json:"result,omitempty"`
+	At          time.Time                `json:"at"`
+}
+
+func newPaymentUpdate(referenceID string, result *momo.RequestToPayResult, source string) PaymentUpdate {
+	return PaymentUpdate{ReferenceID: referenceID, Status: result.Status, Source: source, Result: result, At: time.Now()}
+}
+
+// StatusHub diffuse les changements de statut des paiements à leurs abonnés. Tant qu'un
+// paiement a des abonnés, il est interrogé toutes les PollInterval, une seule fois quel
+// que soit le nombre d'abonnés ; les callbacks sont diffusés dès leur réception.
+type StatusHub struct {
+	PollInterval time.Duration
+
+	client    *momo.Client
+	mu        sync.Mutex
+	watches   map[string]*watch
+	listeners []func(PaymentUpdate)
+}
+
+type watch struct {
+	last        PaymentUpdate
+	subscribers map[chan PaymentUpdate]struct{}
+	stop        chan struct{}
+}
+
+func NewStatusHub(client *momo.Client) *StatusHub {
+	return &StatusHub{
+		PollInterval: DefaultStatusPollInterval,
+		client:       client,
+		watches:      make(map[string]*watch),
+	}
+}
+
+// OnChange enregistre une fonction appelée pour chaque changement de statut diffusé, qu'il
+// vienne d'un callback ou d'une interrogation de MoMo. Elle est appelée hors du verrou du
+// hub, depuis la goroutine qui publie, et ne doit pas bloquer.
+func (h *StatusHub) OnChange(fn func(PaymentUpdate)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listeners = append(h.listeners, fn)
+}
+
+// Watch renvoie les mises à jour d'un paiement : d'abord son statut actuel, puis chaque
+// changement. Le canal est fermé après un statut final ou à l'annulation de ctx.
+// Une erreur est renvoyée si le statut actuel ne peut pas être lu.
+func (h *StatusHub) Watch(ctx context.Context, referenceID string) (<-chan PaymentUpdate, error) {
+	updates := make(chan PaymentUpdate, updateBufferSize)
+
+	h.mu.Lock()
+	w, ok := h.watches[referenceID]
+	if !ok {
+		h.mu.Unlock()
+		result, err := h.client.Collection().WithContext(ctx).GetPaymentStatus(referenceID)
+		if err != nil {
+			return nil, err
+		}
+		current := newPaymentUpdate(referenceID, result, SourcePoll)
+		if momo.IsFinalStatus(current.Status) {
+			updates <- current
+			close(updates)
+			return updates, nil
+		}
+
+		h.mu.Lock()
+		if w, ok = h.watches[referenceID]; !ok {
+			w = &watch{last: current, subscribers: make(map[chan PaymentUpdate]struct{}), stop: make(chan struct{})}
+			h.watches[referenceID] = w
+			go h.poll(referenceID, w)
+		}
+	}
+	w.subscribers[updates] = struct{}{}
+	updates <- w.last
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.unsubscribe(referenceID, w, updates)
+	}()
+	return updates, nil
+}
+
+// Publish diffuse une mise à jour aux abonnés du paiement, si son statut a changé.
+// Après un statut final, remis à chaque abonné même s'il est en retard, les abonnés sont
+// fermés et le suivi s'arrête.
+func (h *StatusHub) Publish(update PaymentUpdate) {
+	if !h.publish(update) {
+		return
+	}
+
+	h.mu.Lock()
+	listeners := h.listeners
+	h.mu.Unlock()
+	for _, fn := range listeners {
+		fn(update)
+	}
+}
+
+// publish remet la mise à jour aux abonnés et renvoie false si le statut n'a pas changé.
+func (h *StatusHub) publish(update PaymentUpdate) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w, ok := h.watches[update.ReferenceID]
+	if !ok || w.last.Status == update.Status {
+		return false
+	}
+	w.last = update
+	final := momo.IsFinalStatus(update.Status)
+	for updates := range w.subscribers {
+		deliver(updates, update, final)
+	}
+
+	if final {
+		for updates := range w.subscribers {
+			close(updates)
+			delete(w.subscribers, updates)
+		}
+		h.remove(update.ReferenceID, w)
+	}
+	return true
+}
+
+// deliver remet une mise à jour à un abonné sans bloquer. Si son tampon est plein, une
+// mise à jour intermédiaire est perdue, mais une mise à jour finale remplace la plus
+// ancienne en attente : l'abonné la reçoit toujours avant la fermeture du canal.
+// h.mu doit être verrouillé, ce qui garantit qu'aucune autre mise à jour n'est envoyée.
+func deliver(updates chan PaymentUpdate, update PaymentUpdate, final bool) {
+	select {
+	case updates <- update:
+		return
+	default:
+	}
+	if !final {
+		log.Printf("Status update %s for reference ID %s dropped for a slow subscriber", update.Status, update.ReferenceID)
+		return
+	}
+
+	select {
+	case dropped := <-updates:
+		log.Printf("Status update %s for reference ID %s replaced by the final status for a slow subscriber", dropped.Status, update.ReferenceID)
+	default:
+	}
+	updates <- update
+}
+
+// PublishCallback diffuse le statut porté par un callback de demande de paiement.
+func (h *StatusHub) PublishCallback(event momo.CallbackEvent) {
+	payment, ok := event.(momo.RequestToPayEvent)
+	if !ok {
+		return
+	}
+	result := payment.Result
+	if result.ReferenceId == "" {
+		result.ReferenceId = payment.ReferenceID
+	}
+	h.Publish(newPaymentUpdate(payment.ReferenceID, &result, SourceCallback))
+}
+
+func (h *StatusHub) unsubscribe(referenceID string, w *watch, updates chan PaymentUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := w.subscribers[updates]; ok {
+		delete(w.subscribers, updates)
+		close(updates)
+	}
+	if len(w.subscribers) == 0 {
+		h.remove(referenceID, w)
+	}
+}
+
+// remove arrête le suivi du paiement. h.mu doit être verrouillé.
+func (h *StatusHub) remove(referenceID string, w *watch) {
+	if h.watches[referenceID] == w {
+		delete(h.watches, referenceID)
+		close(w.stop)
+	}
+}
+
+func (h *StatusHub) poll(referenceID string, w *watch) {
+	ticker := time.NewTicker(h.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), statusPollTimeout)
+		result, err := h.client.Collection().WithContext(ctx).GetPaymentStatus(referenceID)
+		cancel()
+		if err != nil {
+			log.Printf("Error polling status for reference ID %s: %v", referenceID, err)
+			continue
+		}
+		h.Publish(newPaymentUpdate(referenceID, result, SourcePoll))
+	}
+}
@@ -0,0 +1,90 @@
+// Package momotest fournit une fausse API MoMo pour les tests des paquets HTTP.
+package momotest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+)
+
+// PaymentBody est une demande de paiement valide.
+const PaymentBody = `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+
+// NewClient renvoie un client configuré sur une fausse API MoMo qui accepte les paiements,
+// les déclare SUCCESSFUL, renvoie un solde de 1000 EUR et déclare chaque titulaire actif.
+func NewClient(t *testing.T) *momo.Client {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/collection/token/":
+			json.NewEncoder(w).Encode(momo.AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+		case r.URL.Path == "/collection/v1_0/requesttopay":
+			w.WriteHeader(http.StatusAccepted)
+		case strings.HasPrefix(r.URL.Path, "/collection/v2_0/payment/"):
+			json.NewEncoder(w).Encode(momo.RequestToPayResult{ReferenceId: strings.TrimPrefix(r.URL.Path, "/collection/v2_0/payment/"), Status: momo.StatusSuccessful})
+		case r.URL.Path == "/collection/v1_0/account/balance":
+			json.NewEncoder(w).Encode(momo.Balance{AvailableBalance: "1000", Currency: "EUR"})
+		case strings.HasPrefix(r.URL.Path, "/collection/v1_0/accountholder/"):
+			w.Write([]byte(`{"result": true}`))
+		default:
+			t.Errorf("unexpected upstream request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	return momo.NewClientFromConfig(momo.Config{
+		Environment: "sandbox",
+		BaseURL:     upstream.URL,
+		Collection:  momo.Credentials{SubscriptionKey: "test-subscription-key", ApiUserID: "test-api-user-id", ApiKey: "test-api-key"},
+	})
+}
+
+// CheckRoutes exerce les routes de paiement, de statut, de solde et de callbacks montées sur h.
+func CheckRoutes(t *testing.T, h http.Handler) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/request-to-pay", strings.NewReader(PaymentBody)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("request-to-pay: expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Correlation-Id") == "" {
+		t.Error("request-to-pay: expected a correlation ID")
+	}
+	var created struct {
+		ReferenceID string `json:"reference_id"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/payment-status/"+created.ReferenceID, nil))
+	var status momo.RequestToPayResult
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	if rec.Code != http.StatusOK || status.ReferenceId != created.ReferenceID {
+		t.Fatalf("payment-status: unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/get-account-balance", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get-account-balance: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/callbacks/requesttopay/"+created.ReferenceID, strings.NewReader(`{"status": "SUCCESSFUL"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callbacks: expected status 200, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/request-to-pay", strings.NewReader(`{"amount": "ten"}`)))
+	if rec.Code != http.StatusUnprocessableEntity || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("request-to-pay: expected a 422 problem, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,115 @@
+// Package metrics mesure les appels du client MoMo et les routes de la passerelle,
+// et les expose au format Prometheus.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Préfixe des métriques
+const namespace = "momo"
+
+// Collector regroupe les métriques du client et de la passerelle dans son propre registre.
+// Il implémente momo.Metrics.
+type Collector struct {
+	registry *prometheus.Registry
+
+	calls          *prometheus.CounterVec
+	callDuration   *prometheus.HistogramVec
+	tokenRefreshes *prometheus.CounterVec
+	finalStatuses  *prometheus.CounterVec
+	requests       *prometheus.CounterVec
+	requestLatency *prometheus.HistogramVec
+}
+
+var _ momo.Metrics = (*Collector)(nil)
+
+// New crée un Collector avec un registre dédié, qui inclut aussi les métriques du
+// runtime Go et du processus.
+func New() *Collector {
+	c := &Collector{
+		registry: prometheus.NewRegistry(),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "client", Name: "requests_total",
+			Help: "MoMo API calls by operation, HTTP status and MoMo error code.",
+		}, []string{"operation", "status", "code"}),
+		callDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "client", Name: "request_duration_seconds",
+			Help:    "Duration of MoMo API calls by operation.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation"}),
+		tokenRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "client", Name: "token_refreshes_total",
+			Help: "Access token requests by product and result.",
+		}, []string{"product", "result"}),
+		finalStatuses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "payments_final_total",
+			Help: "Operations resolved by a verified callback or a tracker, by product, final status and currency.",
+		}, []string{"product", "status", "currency"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "gateway", Name: "requests_total",
+			Help: "Gateway requests by method, route and HTTP status.",
+		}, []string{"method", "route", "status"}),
+		requestLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "gateway", Name: "request_duration_seconds",
+			Help:    "Duration of gateway requests by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+
+	c.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		c.calls, c.callDuration, c.tokenRefreshes, c.finalStatuses, c.requests, c.requestLatency,
+	)
+	return c
+}
+
+// Registry renvoie le registre, pour y ajouter d'autres métriques.
+func (c *Collector) Registry() *prometheus.Registry {
+	return c.registry
+}
+
+// Handler sert les métriques au format d'exposition Prometheus.
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+func (c *Collector) ObserveCall(operation string, statusCode int, code string, duration time.Duration) {
+	c.calls.WithLabelValues(operation, statusLabel(statusCode), code).Inc()
+	c.callDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+func (c *Collector) ObserveTokenRefresh(product momo.Product, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	c.tokenRefreshes.WithLabelValues(string(product), result).Inc()
+}
+
+func (c *Collector) ObserveFinalStatus(product momo.Product, status, currency string) {
+	c.finalStatuses.WithLabelValues(string(product), status, currency).Inc()
+}
+
+// ObserveRequest mesure une requête de la passerelle. route est le modèle de la route,
+// par exemple /payment-status/:reference_id, pour ne pas créer une série par référence.
+func (c *Collector) ObserveRequest(method, route string, statusCode int, duration time.Duration) {
+	c.requests.WithLabelValues(method, route, statusLabel(statusCode)).Inc()
+	c.requestLatency.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// statusLabel renvoie le statut HTTP, ou "error" quand MoMo n'a pas répondu.
+func statusLabel(statusCode int) string {
+	if statusCode == 0 {
+		return "error"
+	}
+	return strconv.Itoa(statusCode)
+}
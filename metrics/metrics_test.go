@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+)
+
+func TestCollectorExposition(t *testing.T) {
+	c := New()
+	c.ObserveCall("request to pay", http.StatusAccepted, "", 120*time.Millisecond)
+	c.ObserveCall("get payment status", http.StatusNotFound, momo.CodeResourceNotFound, 80*time.Millisecond)
+	c.ObserveCall("get account balance", 0, "", time.Second)
+	c.ObserveTokenRefresh(momo.ProductCollection, nil)
+	c.ObserveTokenRefresh(momo.ProductDisbursement, errors.New("unauthorized"))
+	c.ObserveFinalStatus(momo.ProductCollection, momo.StatusSuccessful, "EUR")
+	c.ObserveRequest(http.MethodGet, "/payment-status/:reference_id", http.StatusOK, 10*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`momo_client_requests_total{code="",operation="request to pay",status="202"} 1`,
+		`momo_client_requests_total{code="RESOURCE_NOT_FOUND",operation="get payment status",status="404"} 1`,
+		`momo_client_requests_total{code="",operation="get account balance",status="error"} 1`,
+		`momo_client_request_duration_seconds_count{operation="request to pay"} 1`,
+		`momo_client_token_refreshes_total{product="collection",result="success"} 1`,
+		`momo_client_token_refreshes_total{product="disbursement",result="error"} 1`,
+		`momo_payments_final_total{currency="EUR",product="collection",status="SUCCESSFUL"} 1`,
+		`momo_gateway_requests_total{method="GET",route="/payment-status/:reference_id",status="200"} 1`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in:\n%s", want, body)
+		}
+	}
+}
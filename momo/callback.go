@@ -0,0 +1,251 @@
+package momo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CallbackType identifie l'opération à laquelle se rapporte une notification MoMo
+type CallbackType string
+
+const (
+	CallbackRequestToPay CallbackType = "requesttopay"
+	CallbackTransfer     CallbackType = "transfer"
+	CallbackDeposit      CallbackType = "deposit"
+	CallbackRefund       CallbackType = "refund"
+	CallbackWithdrawal   CallbackType = "withdrawal"
+)
+
+// Taille maximale acceptée pour le corps d'une notification
+const maxCallbackBodySize = 1 << 20
+
+// Structure pour les informations communes à toutes les notifications
+type CallbackMeta struct {
+	Type        CallbackType `json:"type"`
+	ReferenceID string       `json:"referenceId"`
+	ReceivedAt  time.Time    `json:"receivedAt"`
+}
+
+// CallbackEvent est implémenté par RequestToPayEvent, TransferEvent, DepositEvent,
+// RefundEvent et WithdrawalEvent.
+type CallbackEvent interface {
+	Meta() CallbackMeta
+	Status() string
+}
+
+type RequestToPayEvent struct {
+	CallbackMeta
+	Result RequestToPayResult
+}
+
+type TransferEvent struct {
+	CallbackMeta
+	Result TransferResult
+}
+
+type DepositEvent struct {
+	CallbackMeta
+	Result TransferResult
+}
+
+type RefundEvent struct {
+	CallbackMeta
+	Result TransferResult
+}
+
+type WithdrawalEvent struct {
+	CallbackMeta
+	Result RequestToWithdrawResult
+}
+
+func (e RequestToPayEvent) Meta() CallbackMeta { return e.CallbackMeta }
+func (e TransferEvent) Meta() CallbackMeta     { return e.CallbackMeta }
+func (e DepositEvent) Meta() CallbackMeta      { return e.CallbackMeta }
+func (e RefundEvent) Meta() CallbackMeta       { return e.CallbackMeta }
+func (e WithdrawalEvent) Meta() CallbackMeta   { return e.CallbackMeta }
+
+func (e RequestToPayEvent) Status() string { return e.Result.Status }
+func (e TransferEvent) Status() string     { return e.Result.Status }
+func (e DepositEvent) Status() string      { return e.Result.Status }
+func (e RefundEvent) Status() string       { return e.Result.Status }
+func (e WithdrawalEvent) Status() string   { return e.Result.Status }
+
+// CallbackResult renvoie le modèle de résultat porté par un événement de callback.
+func CallbackResult(event CallbackEvent) interface{} {
+	switch e := event.(type) {
+	case RequestToPayEvent:
+		return e.Result
+	case WithdrawalEvent:
+		return e.Result
+	case TransferEvent:
+		return e.Result
+	case DepositEvent:
+		return e.Result
+	case RefundEvent:
+		return e.Result
+	}
+	return nil
+}
+
+// CallbackFunc reçoit les notifications décodées. Une erreur renvoie un 500 à MoMo.
+type CallbackFunc func(ctx context.Context, event CallbackEvent) error
+
+// CallbackHandler est un http.Handler qui reçoit les notifications PUT/POST de MoMo.
+//
+// Le type d'opération est lu dans le chemin, suivi si besoin de l'identifiant de référence,
+// par exemple /callbacks/requesttopay/{referenceId}. À défaut, la référence est lue dans le
+// paramètre referenceId, l'en-tête X-Reference-Id ou le champ referenceId du corps.
+//
+// Chaque notification est traitée dans un span, enfant du contexte de trace reçu dans les
+// en-têtes s'il y en a un.
+type CallbackHandler struct {
+	// TracerProvider crée les spans des notifications. Par défaut, le fournisseur global d'OpenTelemetry
+	TracerProvider trace.TracerProvider
+
+	fn CallbackFunc
+}
+
+func NewCallbackHandler(fn CallbackFunc) *CallbackHandler {
+	return &CallbackHandler{fn: fn}
+}
+
+func (h *CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	callbackType, referenceID, ok := parseCallbackPath(r.URL.Path)
+	if !ok {
+		callbackType = CallbackType(strings.ToLower(r.URL.Query().Get("type")))
+		if !isCallbackType(callbackType) {
+			log.Printf("Callback received on unknown path %s", r.URL.Path)
+			http.Error(w, "unknown callback type", http.StatusNotFound)
+			return
+		}
+	}
+	if referenceID == "" {
+		referenceID = r.URL.Query().Get("referenceId")
+	}
+	if referenceID == "" {
+		referenceID = r.Header.Get("X-Reference-Id")
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBodySize))
+	if err != nil {
+		log.Printf("Error reading callback body: %v", err)
+		http.Error(w, "cannot read body", http.StatusBadRequest)
+		return
+	}
+	log.Printf("Callback received: type %s, reference ID %s, body: %s", callbackType, referenceID, string(body))
+
+	event, err := DecodeCallback(callbackType, referenceID, body)
+	if err != nil {
+		log.Printf("Error decoding callback: %v", err)
+		http.Error(w, "invalid callback body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, span := h.startSpan(r, event)
+	err = h.fn(ctx, event)
+	endSpan(span, err)
+	if err != nil {
+		log.Printf("Error handling callback %s: %v", event.Meta().ReferenceID, err)
+		http.Error(w, "callback not processed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// startSpan démarre le span de traitement d'une notification.
+func (h *CallbackHandler) startSpan(r *http.Request, event CallbackEvent) (context.Context, trace.Span) {
+	provider := h.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	meta := event.Meta()
+	return provider.Tracer(TracerName).Start(ctx, "momo.callback", trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		attribute.String(AttrCallbackType, string(meta.Type)),
+		attribute.String(AttrReferenceID, meta.ReferenceID),
+		attribute.String(AttrPaymentStatus, event.Status()),
+	))
+}
+
+// DecodeCallback décode le corps d'une notification en événement typé.
+func DecodeCallback(callbackType CallbackType, referenceID string, body []byte) (CallbackEvent, error) {
+	meta := CallbackMeta{Type: callbackType, ReferenceID: referenceID, ReceivedAt: time.Now()}
+
+	switch callbackType {
+	case CallbackRequestToPay, CallbackWithdrawal:
+		var result RequestToPayResult
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		if meta.ReferenceID == "" {
+			meta.ReferenceID = result.ReferenceId
+		}
+		if callbackType == CallbackWithdrawal {
+			return WithdrawalEvent{meta, result}, nil
+		}
+		return RequestToPayEvent{meta, result}, nil
+
+	case CallbackTransfer, CallbackDeposit, CallbackRefund:
+		var result TransferResult
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		if meta.ReferenceID == "" {
+			meta.ReferenceID = result.ReferenceId
+		}
+		switch callbackType {
+		case CallbackDeposit:
+			return DepositEvent{meta, result}, nil
+		case CallbackRefund:
+			return RefundEvent{meta, result}, nil
+		default:
+			return TransferEvent{meta, result}, nil
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown callback type %q", callbackType)
+	}
+}
+
+func isCallbackType(t CallbackType) bool {
+	switch t {
+	case CallbackRequestToPay, CallbackTransfer, CallbackDeposit, CallbackRefund, CallbackWithdrawal:
+		return true
+	}
+	return false
+}
+
+// parseCallbackPath cherche le type d'opération dans le chemin et renvoie le segment suivant
+// comme identifiant de référence.
+func parseCallbackPath(path string) (CallbackType, string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		callbackType := CallbackType(strings.ToLower(segment))
+		if !isCallbackType(callbackType) {
+			continue
+		}
+		if i+1 < len(segments) {
+			return callbackType, segments[i+1], true
+		}
+		return callbackType, "", true
+	}
+	return "", "", false
+}
@@ -0,0 +1,99 @@
+package momo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallbackHandlerDecodesRequestToPay(t *testing.T) {
+	var received CallbackEvent
+	handler := NewCallbackHandler(func(ctx context.Context, event CallbackEvent) error {
+		received = event
+		return nil
+	})
+
+	body := `{
+		"financialTransactionId": "123456789",
+		"externalId": "123456",
+		"amount": "100",
+		"currency": "EUR",
+		"payer": {"partyIdType": "MSISDN", "partyId": "46733123453"},
+		"payerMessage": "Payment for invoice 123456",
+		"payeeNote": "Invoice 123456 payment",
+		"status": "FAILED",
+		"reason": "APPROVAL_REJECTED"
+	}`
+	req := httptest.NewRequest(http.MethodPut, "/callbacks/requesttopay/test-reference-id", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	event, ok := received.(RequestToPayEvent)
+	if !ok {
+		t.Fatalf("expected a RequestToPayEvent, got %T", received)
+	}
+	if event.ReferenceID != "test-reference-id" {
+		t.Fatalf("expected reference ID to be 'test-reference-id', got %s", event.ReferenceID)
+	}
+	if event.Result.Payer.PartyId != "46733123453" || event.Result.FinancialTransactionId != "123456789" {
+		t.Fatalf("unexpected result %+v", event.Result)
+	}
+	if event.Result.Reason == nil || event.Result.Reason.Code != "APPROVAL_REJECTED" {
+		t.Fatalf("expected reason code to be 'APPROVAL_REJECTED', got %+v", event.Result.Reason)
+	}
+}
+
+func TestCallbackHandlerDecodesTransferTypes(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected CallbackType
+	}{
+		{"/callbacks/transfer?referenceId=ref-1", CallbackTransfer},
+		{"/callbacks/deposit/ref-1", CallbackDeposit},
+		{"/callbacks/refund/ref-1", CallbackRefund},
+		{"/callbacks/withdrawal/ref-1", CallbackWithdrawal},
+	}
+
+	for _, tt := range tests {
+		var received CallbackEvent
+		handler := NewCallbackHandler(func(ctx context.Context, event CallbackEvent) error {
+			received = event
+			return nil
+		})
+
+		body := `{"amount": "50", "currency": "EUR", "payee": {"partyIdType": "MSISDN", "partyId": "46733123454"}, "status": "SUCCESSFUL"}`
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body)))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", tt.path, rec.Code)
+		}
+		if received.Meta().Type != tt.expected || received.Meta().ReferenceID != "ref-1" {
+			t.Fatalf("%s: unexpected meta %+v", tt.path, received.Meta())
+		}
+		if received.Status() != StatusSuccessful {
+			t.Fatalf("%s: expected status SUCCESSFUL, got %s", tt.path, received.Status())
+		}
+		if transfer, ok := received.(TransferEvent); ok && transfer.Result.Payee.PartyId != "46733123454" {
+			t.Fatalf("%s: unexpected payee %+v", tt.path, transfer.Result.Payee)
+		}
+	}
+}
+
+func TestCallbackHandlerRejectsUnknownPath(t *testing.T) {
+	handler := NewCallbackHandler(func(ctx context.Context, event CallbackEvent) error {
+		t.Fatal("callback function should not be called")
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/callbacks/unknown", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,188 @@
+package momo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownReference signale un callback pour une référence que le client n'a pas créée
+	ErrUnknownReference = errors.New("callback for unknown reference")
+	// ErrCallbackTypeMismatch signale un callback dont l'opération ne correspond pas à la requête en attente
+	ErrCallbackTypeMismatch = errors.New("callback type does not match the pending request")
+	// ErrCallbackStatusMismatch signale un callback dont le statut n'est pas confirmé par MoMo
+	ErrCallbackStatusMismatch = errors.New("callback status not confirmed by MoMo")
+)
+
+// Durée par défaut pendant laquelle un couple référence/statut déjà traité est ignoré
+const defaultCallbackDedupTTL = 24 * time.Hour
+
+// CallbackVerifier filtre les callbacks avant de les transmettre :
+//   - les doublons (même type, même référence et même statut) sont acquittés sans être retransmis ;
+//   - les références inconnues du PendingStore du client sont signalées et ignorées ;
+//   - le statut est confirmé auprès de MoMo avant l'acquittement. En cas de désaccord,
+//     le callback n'est pas acquitté pour que MoMo le renvoie plus tard.
+//
+// Handle s'utilise comme CallbackFunc de NewCallbackHandler.
+type CallbackVerifier struct {
+	// Flagged est appelée pour chaque callback ignoré ou non confirmé. Par défaut, il est journalisé.
+	Flagged func(ctx context.Context, event CallbackEvent, reason error)
+	// DedupTTL est la durée de rétention des callbacks déjà traités.
+	DedupTTL time.Duration
+
+	client *Client
+	next   CallbackFunc
+
+	mu     sync.Mutex
+	seen   map[string]*seenReference
+	expiry expiryQueue
+}
+
+// seenReference regroupe les statuts déjà traités pour une référence. Elle est oubliée
+// DedupTTL après le dernier statut reçu.
+type seenReference struct {
+	statuses  map[string]bool
+	expiresAt time.Time
+}
+
+// NewCallbackVerifier crée un vérificateur qui transmet les callbacks valides à next.
+// Si le client n'a pas de PendingStore, un MemoryPendingStore lui est attribué.
+func NewCallbackVerifier(client *Client, next CallbackFunc) *CallbackVerifier {
+	if client.Pending == nil {
+		client.Pending = NewMemoryPendingStore()
+	}
+	return &CallbackVerifier{
+		DedupTTL: defaultCallbackDedupTTL,
+		client:   client,
+		next:     next,
+		seen:     make(map[string]*seenReference),
+	}
+}
+
+// Handle vérifie un callback. La référence et le type sont rapprochés de l'opération en
+// attente avant que le statut ne soit marqué comme traité : un callback refusé ne peut
+// donc pas faire ignorer comme doublon le vrai callback qui le suit.
+func (v *CallbackVerifier) Handle(ctx context.Context, event CallbackEvent) error {
+	meta := event.Meta()
+	if meta.ReferenceID == "" {
+		v.flag(ctx, event, fmt.Errorf("%w: missing reference ID", ErrUnknownReference))
+		return nil
+	}
+	key := seenKey(meta)
+
+	pending, ok, err := v.client.Pending.Get(ctx, meta.ReferenceID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Après un statut final, l'opération n'est plus en attente : MoMo renvoie un doublon
+		if v.handled(key, event.Status()) {
+			log.Printf("Duplicate callback for reference ID %s with status %s ignored", meta.ReferenceID, event.Status())
+			return nil
+		}
+		v.flag(ctx, event, fmt.Errorf("%w: %s", ErrUnknownReference, meta.ReferenceID))
+		return nil
+	}
+	LinkPending(ctx, pending)
+	if pending.Type != meta.Type {
+		v.flag(ctx, event, fmt.Errorf("%w: expected %s, got %s", ErrCallbackTypeMismatch, pending.Type, meta.Type))
+		return nil
+	}
+
+	if !v.claim(key, event.Status()) {
+		log.Printf("Duplicate callback for reference ID %s with status %s ignored", meta.ReferenceID, event.Status())
+		return nil
+	}
+	if err := v.confirm(ctx, event, pending); err != nil {
+		v.release(key, event.Status())
+		return err
+	}
+	return nil
+}
+
+// confirm vérifie le statut auprès de MoMo, puis transmet le callback à next.
+func (v *CallbackVerifier) confirm(ctx context.Context, event CallbackEvent, pending PendingRequest) error {
+	confirmed, _, err := v.client.pendingResult(ctx, pending)
+	if err != nil {
+		return fmt.Errorf("cannot confirm callback status: %w", err)
+	}
+	if confirmed != event.Status() {
+		err := fmt.Errorf("%w: callback says %s, MoMo says %s", ErrCallbackStatusMismatch, event.Status(), confirmed)
+		v.flag(ctx, event, err)
+		return err
+	}
+
+	if err := v.next(ctx, event); err != nil {
+		return err
+	}
+
+	if IsFinalStatus(confirmed) {
+		if err := v.client.Pending.Remove(ctx, pending.ReferenceID); err != nil {
+			log.Printf("Error removing pending request %s: %v", pending.ReferenceID, err)
+		}
+	}
+	return nil
+}
+
+func (v *CallbackVerifier) flag(ctx context.Context, event CallbackEvent, reason error) {
+	if v.Flagged != nil {
+		v.Flagged(ctx, event, reason)
+		return
+	}
+	log.Printf("Flagged callback %s for reference ID %s: %v", event.Meta().Type, event.Meta().ReferenceID, reason)
+}
+
+// seenKey identifie les callbacks d'une opération : sa référence et son type.
+func seenKey(meta CallbackMeta) string {
+	return string(meta.Type) + "|" + meta.ReferenceID
+}
+
+// handled indique si le statut a déjà été traité pour key.
+func (v *CallbackVerifier) handled(key, status string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	ref, ok := v.seen[key]
+	return ok && ref.statuses[status] && time.Now().Before(ref.expiresAt)
+}
+
+// claim marque le statut d'une référence comme traité et renvoie false s'il l'était déjà.
+func (v *CallbackVerifier) claim(key, status string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now()
+	v.expiry.evict(now, func(key string, expiresAt time.Time) {
+		if ref, ok := v.seen[key]; ok && ref.expiresAt.Equal(expiresAt) {
+			delete(v.seen, key)
+		}
+	})
+
+	ref, ok := v.seen[key]
+	if !ok {
+		ref = &seenReference{statuses: make(map[string]bool)}
+		v.seen[key] = ref
+	}
+	if ref.statuses[status] {
+		return false
+	}
+	ref.statuses[status] = true
+	ref.expiresAt = now.Add(v.DedupTTL)
+	v.expiry.push(key, ref.expiresAt)
+	return true
+}
+
+func (v *CallbackVerifier) release(key, status string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if ref, ok := v.seen[key]; ok {
+		delete(ref.statuses, status)
+		if len(ref.statuses) == 0 {
+			delete(v.seen, key)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package momo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallbackVerifier(t *testing.T) {
+	upstreamStatus := StatusSuccessful
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/collection/token/":
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+		case r.URL.Path == "/collection/v1_0/requesttopay":
+			w.WriteHeader(http.StatusAccepted)
+		case strings.HasPrefix(r.URL.Path, "/collection/v2_0/payment/"):
+			json.NewEncoder(w).Encode(RequestToPayResult{Status: upstreamStatus})
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{
+		Environment: "sandbox",
+		BaseURL:     ts.URL,
+		Collection:  Credentials{SubscriptionKey: "collection-key", ApiUserID: "user-c", ApiKey: "key-c"},
+	})
+
+	var delivered []CallbackEvent
+	var flagged []error
+	verifier := NewCallbackVerifier(client, func(ctx context.Context, event CallbackEvent) error {
+		delivered = append(delivered, event)
+		return nil
+	})
+	verifier.Flagged = func(ctx context.Context, event CallbackEvent, reason error) {
+		flagged = append(flagged, reason)
+	}
+	handler := NewCallbackHandler(verifier.Handle)
+
+	referenceID, err := client.Collection().RequestToPay(RequestToPay{
+		Amount:     "100",
+		Currency:   "EUR",
+		ExternalId: "123456",
+		Payer:      Payer{PartyIdType: "MSISDN", PartyId: "46733123453"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(path, status string) int {
+		rec := httptest.NewRecorder()
+		body := `{"status": "` + status + `"}`
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, path, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	upstreamStatus = StatusPending
+	if code := send("/callbacks/requesttopay/"+referenceID, StatusSuccessful); code != http.StatusInternalServerError {
+		t.Fatalf("expected unconfirmed callback to be rejected, got %d", code)
+	}
+	if len(flagged) != 1 || !errors.Is(flagged[0], ErrCallbackStatusMismatch) {
+		t.Fatalf("expected a status mismatch to be flagged, got %v", flagged)
+	}
+
+	upstreamStatus = StatusSuccessful
+	for i := 0; i < 2; i++ {
+		if code := send("/callbacks/requesttopay/"+referenceID, StatusSuccessful); code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", code)
+		}
+	}
+	if len(delivered) != 1 {
+		t.Fatalf("expected one delivered callback, got %d", len(delivered))
+	}
+	if _, ok, _ := client.Pending.Get(context.Background(), referenceID); ok {
+		t.Fatal("expected pending request to be removed after a final status")
+	}
+
+	if code := send("/callbacks/requesttopay/spoofed-reference", StatusSuccessful); code != http.StatusOK {
+		t.Fatalf("expected unknown reference to be acknowledged, got %d", code)
+	}
+	if len(delivered) != 1 {
+		t.Fatal("expected unknown reference not to be delivered")
+	}
+	if len(flagged) != 2 || !errors.Is(flagged[1], ErrUnknownReference) {
+		t.Fatalf("expected unknown reference to be flagged, got %v", flagged)
+	}
+}
+
+func TestCallbackVerifierForgetsExpiredReferences(t *testing.T) {
+	verifier := NewCallbackVerifier(NewClientFromConfig(Config{}), nil)
+	verifier.DedupTTL = 20 * time.Millisecond
+
+	if !verifier.claim("ref-1", StatusPending) || !verifier.claim("ref-1", StatusSuccessful) {
+		t.Fatal("expected new statuses to be claimed")
+	}
+	if verifier.claim("ref-1", StatusSuccessful) {
+		t.Fatal("expected a duplicate status to be refused")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	verifier.claim("ref-2", StatusPending)
+	if _, ok := verifier.seen["ref-1"]; ok {
+		t.Fatal("expected the expired reference to be forgotten")
+	}
+	if len(verifier.seen) != 1 || len(verifier.expiry.entries) != 1 {
+		t.Fatalf("expected only ref-2 to be kept, got %d references and %d deadlines", len(verifier.seen), len(verifier.expiry.entries))
+	}
+}
+
+func TestRejectedCallbackDoesNotHideValidOne(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/collection/token/" {
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+			return
+		}
+		json.NewEncoder(w).Encode(RequestToPayResult{Status: StatusSuccessful})
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{BaseURL: ts.URL, Collection: Credentials{SubscriptionKey: "key", ApiUserID: "user", ApiKey: "secret"}})
+	var delivered int
+	verifier := NewCallbackVerifier(client, func(ctx context.Context, event CallbackEvent) error {
+		delivered++
+		return nil
+	})
+	verifier.Flagged = func(ctx context.Context, event CallbackEvent, reason error) {}
+
+	ctx := context.Background()
+	payment := func(callbackType CallbackType) CallbackEvent {
+		return RequestToPayEvent{CallbackMeta: CallbackMeta{Type: callbackType, ReferenceID: "ref-1"}, Result: RequestToPayResult{Status: StatusSuccessful}}
+	}
+
+	// Le callback arrive avant l'enregistrement de l'opération, puis un faux callback d'un autre type
+	verifier.Handle(ctx, payment(CallbackRequestToPay))
+	client.Pending.Add(ctx, PendingRequest{ReferenceID: "ref-1", Type: CallbackRequestToPay, Product: ProductCollection})
+	verifier.Handle(ctx, payment(CallbackTransfer))
+	if delivered != 0 {
+		t.Fatalf("expected rejected callbacks not to be delivered, got %d", delivered)
+	}
+
+	if err := verifier.Handle(ctx, payment(CallbackRequestToPay)); err != nil {
+		t.Fatal(err)
+	}
+	if delivered != 1 {
+		t.Fatal("expected the valid callback to be delivered after the rejected ones")
+	}
+}
@@ -2,14 +2,19 @@ package momo
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	neturl "net/url"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
@@ -26,19 +31,99 @@ func Init() {
 	}
 }
 
-func NewClient() *Client {
+// NewClient crée un client depuis les variables d'environnement, complétées par le store
+// d'identifiants (voir CredentialStoreFromEnv) pour les champs qu'elles ne définissent pas.
+// Il renvoie une erreur si le store existe mais ne peut pas être lu.
+func NewClient() (*Client, error) {
+	cfg, err := mergeStoreConfig(ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	return NewClientFromConfig(cfg), nil
+}
+
+// NewClientFromConfig crée un client à partir d'une configuration par produit.
+// Les champs ApiKey, ApiUserID et SubscriptionKey reprennent les identifiants collection.
+func NewClientFromConfig(cfg Config) *Client {
 	return &Client{
-		ApiKey:          os.Getenv("API_KEY"),
-		ApiUserID:       os.Getenv("API_USER_ID"),
-		SubscriptionKey: os.Getenv("SUBSCRIPTION_KEY"),
-		Environment:     os.Getenv("ENVIRONMENT"),
+		ApiKey:          cfg.Collection.ApiKey,
+		ApiUserID:       cfg.Collection.ApiUserID,
+		SubscriptionKey: cfg.Collection.SubscriptionKey,
+		Environment:     cfg.Environment,
+		BaseURL:         cfg.BaseURL,
+		config:          cfg,
+	}
+}
+
+// ConfigFromEnv lit la configuration depuis les variables d'environnement.
+// Les variables préfixées par produit (COLLECTION_API_KEY, DISBURSEMENT_SUBSCRIPTION_KEY, ...)
+// sont prioritaires sur API_KEY, API_USER_ID et SUBSCRIPTION_KEY.
+func ConfigFromEnv() Config {
+	return Config{
+		Environment:  os.Getenv("ENVIRONMENT"),
+		BaseURL:      os.Getenv("BASE_URL"),
+		Collection:   credentialsFromEnv("COLLECTION"),
+		Disbursement: credentialsFromEnv("DISBURSEMENT"),
+		Remittance:   credentialsFromEnv("REMITTANCE"),
+	}
+}
+
+func credentialsFromEnv(prefix string) Credentials {
+	lookup := func(name string) string {
+		if value := os.Getenv(prefix + "_" + name); value != "" {
+			return value
+		}
+		return os.Getenv(name)
+	}
+	return Credentials{
+		SubscriptionKey: lookup("SUBSCRIPTION_KEY"),
+		ApiUserID:       lookup("API_USER_ID"),
+		ApiKey:          lookup("API_KEY"),
+		CallbackHost:    lookup("CALLBACK_HOST"),
+	}
+}
+
+// Endpoint renvoie l'URL de l'API MoMo utilisée par le client.
+func (c *Client) Endpoint() string {
+	return c.endpoint()
+}
+
+// redactHeaders renvoie une copie des en-têtes à journaliser, sans les identifiants.
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range []string{"Authorization", "Ocp-Apim-Subscription-Key"} {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "[REDACTED]")
+		}
+	}
+	return redacted
+}
+
+func (c *Client) endpoint() string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+	return baseURL
+}
+
+func (c *Client) collectionCredentials() Credentials {
+	return Credentials{
+		SubscriptionKey: c.SubscriptionKey,
+		ApiUserID:       c.ApiUserID,
+		ApiKey:          c.ApiKey,
+		CallbackHost:    c.config.Collection.CallbackHost,
 	}
 }
 
 func (c *Client) CreateAPIUser(referenceID, callbackHost string) error {
-	url := fmt.Sprintf("%s/v1_0/apiuser", baseURL)
-	if callbackHost == "" {
-		callbackHost = "string"
+	return c.createAPIUser(context.Background(), c.SubscriptionKey, referenceID, callbackHost)
+}
+
+func (c *Client) createAPIUser(ctx context.Context, subscriptionKey, referenceID, callbackHost string) error {
+	url := fmt.Sprintf("%s/v1_0/apiuser", c.endpoint())
+	if err := ValidateCallbackHost(callbackHost); err != nil {
+		log.Printf("Invalid callback host %q: %v", callbackHost, err)
+		return err
 	}
 	reqBody, err := json.Marshal(map[string]string{"providerCallbackHost": callbackHost})
 	if err != nil {
@@ -46,23 +131,22 @@ func (c *Client) CreateAPIUser(referenceID, callbackHost string) error {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		log.Printf("Error creating request: %v", err)
 		return err
 	}
 
 	req.Header.Set("X-Reference-Id", referenceID)
-	req.Header.Set("Ocp-Apim-Subscription-Key", c.SubscriptionKey)
+	req.Header.Set("Ocp-Apim-Subscription-Key", subscriptionKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Cache-Control", "no-cache")
 
 	log.Printf("Making request to %s with reference ID %s and callback host %s", url, referenceID, callbackHost)
-	log.Printf("Request headers: %v", req.Header)
+	log.Printf("Request headers: %v", redactHeaders(req.Header))
 	log.Printf("Request body: %s", reqBody)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do("create API user", req)
 	if err != nil {
 		log.Printf("Error making request: %v", err)
 		return err
@@ -77,9 +161,9 @@ func (c *Client) CreateAPIUser(referenceID, callbackHost string) error {
 	log.Printf("Response status: %d, body: %s", resp.StatusCode, string(body))
 
 	if resp.StatusCode != http.StatusCreated {
-		errMsg := fmt.Sprintf("failed to create API user, status code: %d, response: %s", resp.StatusCode, string(body))
-		log.Println(errMsg)
-		return fmt.Errorf(errMsg)
+		err := newAPIError("create API user", resp.StatusCode, body)
+		log.Println(err)
+		return err
 	}
 
 	log.Println("API user created successfully")
@@ -87,22 +171,25 @@ func (c *Client) CreateAPIUser(referenceID, callbackHost string) error {
 }
 
 func (c *Client) CreateAPIKey(referenceID string) (string, error) {
-	url := fmt.Sprintf("%s/v1_0/apiuser/%s/apikey", baseURL, referenceID)
+	return c.createAPIKey(context.Background(), c.SubscriptionKey, referenceID)
+}
 
-	req, err := http.NewRequest("POST", url, nil)
+func (c *Client) createAPIKey(ctx context.Context, subscriptionKey, referenceID string) (string, error) {
+	url := fmt.Sprintf("%s/v1_0/apiuser/%s/apikey", c.endpoint(), neturl.PathEscape(referenceID))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
 	if err != nil {
 		log.Printf("Error creating request: %v", err)
 		return "", err
 	}
 
-	req.Header.Set("Ocp-Apim-Subscription-Key", c.SubscriptionKey)
+	req.Header.Set("Ocp-Apim-Subscription-Key", subscriptionKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	log.Printf("Making request to %s to create API key for reference ID %s", url, referenceID)
-	log.Printf("Request headers: %v", req.Header)
+	log.Printf("Request headers: %v", redactHeaders(req.Header))
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do("create API key", req)
 	if err != nil {
 		log.Printf("Error making request: %v", err)
 		return "", err
@@ -114,12 +201,12 @@ func (c *Client) CreateAPIKey(referenceID string) (string, error) {
 		log.Printf("Error reading response body: %v", err)
 		return "", err
 	}
-	log.Printf("Response status: %d, body: %s", resp.StatusCode, string(body))
+	log.Printf("Response status: %d", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusCreated {
-		errMsg := fmt.Sprintf("failed to create API key, status code: %d, response: %s", resp.StatusCode, string(body))
-		log.Println(errMsg)
-		return "", fmt.Errorf(errMsg)
+		err := newAPIError("create API key", resp.StatusCode, body)
+		log.Println(err)
+		return "", err
 	}
 
 	var result struct {
@@ -134,22 +221,119 @@ func (c *Client) CreateAPIKey(referenceID string) (string, error) {
 	return result.APIKey, nil
 }
 
+func (c *Client) GetAPIUser(ctx context.Context, referenceID string) (*APIUser, error) {
+	return c.getAPIUser(ctx, c.SubscriptionKey, referenceID)
+}
+
+func (c *Client) getAPIUser(ctx context.Context, subscriptionKey, referenceID string) (*APIUser, error) {
+	url := fmt.Sprintf("%s/v1_0/apiuser/%s", c.endpoint(), neturl.PathEscape(referenceID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		log.Printf("Error creating request: %v", err)
+		return nil, err
+	}
+
+	req.Header.Set("Ocp-Apim-Subscription-Key", subscriptionKey)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	log.Printf("Making request to %s to get API user %s", url, referenceID)
+	resp, err := c.do("get API user", req)
+	if err != nil {
+		log.Printf("Error making request: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading response body: %v", err)
+		return nil, err
+	}
+	log.Printf("Response status: %d, body: %s", resp.StatusCode, string(body))
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("get API user", resp.StatusCode, body)
+	}
+
+	var apiUser APIUser
+	if err := json.Unmarshal(body, &apiUser); err != nil {
+		log.Printf("Error unmarshaling response body: %v", err)
+		return nil, err
+	}
+
+	return &apiUser, nil
+}
+
+// ValidateCallbackHost vérifie qu'un providerCallbackHost est un nom d'hôte valide,
+// éventuellement précédé du schéma http ou https.
+func ValidateCallbackHost(callbackHost string) error {
+	if strings.TrimSpace(callbackHost) == "" {
+		return fmt.Errorf("%w: callback host is empty", ErrInvalidCallbackHost)
+	}
+
+	host, err := callbackHostname(callbackHost)
+	if err != nil {
+		return err
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if len(host) > 253 {
+		return fmt.Errorf("%w: %q is too long", ErrInvalidCallbackHost, callbackHost)
+	}
+	for _, label := range strings.Split(host, ".") {
+		if !hostLabelPattern.MatchString(label) {
+			return fmt.Errorf("%w: %q is not a valid host name", ErrInvalidCallbackHost, callbackHost)
+		}
+	}
+	return nil
+}
+
+var hostLabelPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// callbackHostname extrait le nom d'hôte d'un callback host, avec ou sans schéma.
+func callbackHostname(callbackHost string) (string, error) {
+	raw := strings.TrimSpace(callbackHost)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := neturl.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidCallbackHost, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w: unsupported scheme %q", ErrInvalidCallbackHost, u.Scheme)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" || (u.Path != "" && u.Path != "/") {
+		return "", fmt.Errorf("%w: %q must be a host, not a full URL", ErrInvalidCallbackHost, callbackHost)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("%w: %q has no host", ErrInvalidCallbackHost, callbackHost)
+	}
+	return strings.ToLower(u.Hostname()), nil
+}
+
 func (c *Client) GetAuthToken() (*AuthToken, error) {
-	url := fmt.Sprintf("%s/collection/token/", baseURL)
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.ApiUserID, c.ApiKey)))
+	return c.getAuthToken(context.Background(), ProductCollection, c.collectionCredentials())
+}
 
-	req, err := http.NewRequest("POST", url, nil)
+func (c *Client) getAuthToken(ctx context.Context, product Product, creds Credentials) (*AuthToken, error) {
+	url := fmt.Sprintf("%s/%s/token/", c.endpoint(), neturl.PathEscape(string(product)))
+	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", creds.ApiUserID, creds.ApiKey)))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Basic %s", auth))
-	req.Header.Set("Ocp-Apim-Subscription-Key", c.SubscriptionKey)
+	req.Header.Set("Ocp-Apim-Subscription-Key", creds.SubscriptionKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	log.Printf("Making request to %s to get auth token", url)
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do("get auth token", req)
 	if err != nil {
 		return nil, err
 	}
@@ -159,10 +343,10 @@ func (c *Client) GetAuthToken() (*AuthToken, error) {
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("Response status: %d, body: %s", resp.StatusCode, string(body))
+	log.Printf("Response status: %d", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get auth token, status code: %d, response: %s", resp.StatusCode, string(body))
+		return nil, newAPIError("get auth token", resp.StatusCode, body)
 	}
 
 	var authToken AuthToken
@@ -174,7 +358,7 @@ func (c *Client) GetAuthToken() (*AuthToken, error) {
 }
 
 func (c *Client) CreateOauth2Token(authReqID string) (*Oauth2TokenResponse, error) {
-	url := fmt.Sprintf("%s/collection/oauth2/token/", baseURL)
+	url := fmt.Sprintf("%s/collection/oauth2/token/", c.endpoint())
 
 	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.ApiUserID, c.ApiKey)))
 	data := fmt.Sprintf("grant_type=urn:openid:params:grant-type:ciba&auth_req_id=%s", authReqID)
@@ -192,8 +376,7 @@ func (c *Client) CreateOauth2Token(authReqID string) (*Oauth2TokenResponse, erro
 	req.Header.Set("Ocp-Apim-Subscription-Key", c.SubscriptionKey)
 
 	log.Printf("Making request to %s to get oauth2 token", url)
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do("get oauth2 token", req)
 	if err != nil {
 		log.Printf("Error making request: %v", err)
 		return nil, err
@@ -205,10 +388,10 @@ func (c *Client) CreateOauth2Token(authReqID string) (*Oauth2TokenResponse, erro
 		log.Printf("Error reading response body: %v", err)
 		return nil, err
 	}
-	log.Printf("Response status: %d, body: %s", resp.StatusCode, string(body))
+	log.Printf("Response status: %d", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get oauth2 token, status code: %d, response: %s", resp.StatusCode, string(body))
+		return nil, newAPIError("get oauth2 token", resp.StatusCode, body)
 	}
 
 	var oauth2Token Oauth2TokenResponse
@@ -221,22 +404,29 @@ func (c *Client) CreateOauth2Token(authReqID string) (*Oauth2TokenResponse, erro
 }
 
 func (c *Client) GetAccountBalance(token string) (*Balance, error) {
-	url := fmt.Sprintf("%s/collection/v1_0/account/balance", baseURL)
+	return c.getAccountBalance(context.Background(), ProductCollection, c.SubscriptionKey, token, "")
+}
+
+// getAccountBalance lit le solde du compte, dans la devise demandée si currency n'est pas vide.
+func (c *Client) getAccountBalance(ctx context.Context, product Product, subscriptionKey, token, currency string) (*Balance, error) {
+	url := fmt.Sprintf("%s/%s/v1_0/account/balance", c.endpoint(), neturl.PathEscape(string(product)))
+	if currency != "" {
+		url += "/" + currency
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	req.Header.Set("X-Target-Environment", c.Environment)
-	req.Header.Set("Ocp-Apim-Subscription-Key", c.SubscriptionKey)
+	req.Header.Set("Ocp-Apim-Subscription-Key", subscriptionKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Cache-Control", "no-cache")
 
 	log.Printf("Making request to %s to get account balance", url)
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do("get account balance", req)
 	if err != nil {
 		return nil, err
 	}
@@ -249,7 +439,7 @@ func (c *Client) GetAccountBalance(token string) (*Balance, error) {
 	log.Printf("Response status: %d, body: %s", resp.StatusCode, string(body))
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get account balance, status code: %d, response: %s", resp.StatusCode, string(body))
+		return nil, newAPIError("get account balance", resp.StatusCode, body)
 	}
 
 	var balance Balance
@@ -261,65 +451,278 @@ func (c *Client) GetAccountBalance(token string) (*Balance, error) {
 }
 
 func (c *Client) RequestToPay(token string, request RequestToPay) (string, error) {
-	url := fmt.Sprintf("%s/collection/v1_0/requesttopay", baseURL)
+	ctx := context.Background()
+	if err := c.checkCallbackURL(ctx, c.collectionCredentials(), request.CallbackURL); err != nil {
+		return "", err
+	}
+	return c.requestToPay(ctx, c.SubscriptionKey, token, request)
+}
+
+func (c *Client) requestToPay(ctx context.Context, subscriptionKey, token string, request RequestToPay) (string, error) {
+	url := fmt.Sprintf("%s/collection/v1_0/requesttopay", c.endpoint())
+	referenceID, err := c.submit(ctx, url, "request payment", subscriptionKey, token, request, request.CallbackURL)
+	if err != nil {
+		return "", err
+	}
+	c.recordPending(ctx, PendingRequest{
+		ReferenceID: referenceID,
+		Type:        CallbackRequestToPay,
+		Product:     ProductCollection,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		ExternalId:  request.ExternalId,
+		PartyId:     request.Payer.PartyId,
+		CallerID:    request.CallerID,
+	})
+	return referenceID, nil
+}
+
+func (c *Client) requestToWithdraw(ctx context.Context, subscriptionKey, token string, request RequestToPay) (string, error) {
+	url := fmt.Sprintf("%s/collection/v1_0/requesttowithdraw", c.endpoint())
+	referenceID, err := c.submit(ctx, url, "request withdrawal", subscriptionKey, token, request, request.CallbackURL)
+	if err != nil {
+		return "", err
+	}
+	c.recordPending(ctx, PendingRequest{
+		ReferenceID: referenceID,
+		Type:        CallbackWithdrawal,
+		Product:     ProductCollection,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		ExternalId:  request.ExternalId,
+		PartyId:     request.Payer.PartyId,
+		CallerID:    request.CallerID,
+	})
+	return referenceID, nil
+}
+
+func (c *Client) transfer(ctx context.Context, product Product, subscriptionKey, token string, request Transfer) (string, error) {
+	url := fmt.Sprintf("%s/%s/v1_0/transfer", c.endpoint(), neturl.PathEscape(string(product)))
+	referenceID, err := c.submit(ctx, url, "transfer", subscriptionKey, token, request, request.CallbackURL)
+	if err != nil {
+		return "", err
+	}
+	c.recordPending(ctx, PendingRequest{
+		ReferenceID: referenceID,
+		Type:        CallbackTransfer,
+		Product:     product,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		ExternalId:  request.ExternalId,
+		PartyId:     request.Payee.PartyId,
+		CallerID:    request.CallerID,
+	})
+	return referenceID, nil
+}
+
+// recordPending enregistre une opération acceptée pour la rapprocher plus tard de son callback.
+func (c *Client) recordPending(ctx context.Context, pending PendingRequest) {
+	if c.Pending == nil {
+		return
+	}
+	pending.CreatedAt = time.Now()
+	pending.TraceParent = traceParent(ctx)
+	if err := c.Pending.Add(ctx, pending); err != nil {
+		log.Printf("Error recording pending request %s: %v", pending.ReferenceID, err)
+	}
+}
+
+// getResult lit le résultat d'une opération asynchrone dans out.
+func (c *Client) getResult(ctx context.Context, url, action, subscriptionKey, token string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		log.Printf("Error creating request: %v", err)
+		return err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("X-Target-Environment", c.Environment)
+	req.Header.Set("Ocp-Apim-Subscription-Key", subscriptionKey)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	log.Printf("Making request to %s to get %s", url, action)
+	resp, err := c.do("get "+action, req)
+	if err != nil {
+		log.Printf("Error making request: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading response body: %v", err)
+		return err
+	}
+	log.Printf("Response status: %d, body: %s", resp.StatusCode, string(body))
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError("get "+action, resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		log.Printf("Error unmarshaling response body: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) getWithdrawalStatus(ctx context.Context, subscriptionKey, referenceID, token string) (*RequestToWithdrawResult, error) {
+	url := fmt.Sprintf("%s/collection/v1_0/requesttowithdraw/%s", c.endpoint(), neturl.PathEscape(referenceID))
+	var result RequestToWithdrawResult
+	if err := c.getResult(ctx, url, "withdrawal status", subscriptionKey, token, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) validateAccountHolder(ctx context.Context, product Product, subscriptionKey, token string, party Party) (bool, error) {
+	url := fmt.Sprintf("%s/%s/v1_0/accountholder/%s/%s/active", c.endpoint(), neturl.PathEscape(string(product)),
+		neturl.PathEscape(strings.ToLower(party.PartyIdType)), neturl.PathEscape(party.PartyId))
+	var result struct {
+		Result bool `json:"result"`
+	}
+	if err := c.getResult(ctx, url, "account holder status", subscriptionKey, token, &result); err != nil {
+		return false, err
+	}
+	return result.Result, nil
+}
+
+// getTransferResult lit le statut d'un transfert, d'un dépôt ou d'un remboursement.
+func (c *Client) getTransferResult(ctx context.Context, product Product, operation CallbackType, subscriptionKey, referenceID, token string) (*TransferResult, error) {
+	url := fmt.Sprintf("%s/%s/v1_0/%s/%s", c.endpoint(), neturl.PathEscape(string(product)), neturl.PathEscape(string(operation)), neturl.PathEscape(referenceID))
+	var result TransferResult
+	if err := c.getResult(ctx, url, string(operation)+" status", subscriptionKey, token, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// submit envoie une opération asynchrone sous une nouvelle référence et renvoie cette référence.
+// Une erreur survenue après l'envoi, dont l'issue est inconnue, est une *SubmitError.
+func (c *Client) submit(ctx context.Context, url, action, subscriptionKey, token string, payload interface{}, callbackURL string) (string, error) {
 	referenceID := uuid.New().String()
 
-	reqBody, err := json.Marshal(request)
+	reqBody, err := json.Marshal(payload)
 	if err != nil {
 		log.Printf("Error marshaling request body: %v", err)
 		return "", err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		log.Printf("Error creating request: %v", err)
 		return "", err
 	}
 
-	log.Printf("Token: %s", token)
 	log.Printf("Environment: %s", c.Environment)
-	log.Printf("Subscription Key: %s", c.SubscriptionKey)
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	req.Header.Set("X-Reference-Id", referenceID)
 	req.Header.Set("X-Target-Environment", c.Environment)
-	req.Header.Set("Ocp-Apim-Subscription-Key", c.SubscriptionKey)
+	req.Header.Set("Ocp-Apim-Subscription-Key", subscriptionKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Cache-Control", "no-cache")
+	if callbackURL != "" {
+		req.Header.Set("X-Callback-Url", callbackURL)
+	}
 
 	log.Printf("Making request to %s with reference ID %s", url, referenceID)
-	log.Printf("Request headers: %v", req.Header)
+	log.Printf("Request headers: %v", redactHeaders(req.Header))
 	log.Printf("Request body: %s", reqBody)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do(action, req)
 	if err != nil {
 		log.Printf("Error making request: %v", err)
-		return "", err
+		return "", &SubmitError{ReferenceID: referenceID, Err: err}
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("Error reading response body: %v", err)
-		return "", err
+		return "", &SubmitError{ReferenceID: referenceID, Err: err}
 	}
 	log.Printf("Response status: %d, body: %s", resp.StatusCode, string(body))
 
 	if resp.StatusCode != http.StatusAccepted {
-		errMsg := fmt.Sprintf("failed to request payment, status code: %d, response: %s", resp.StatusCode, string(body))
-		log.Println(errMsg)
-		return "", fmt.Errorf(errMsg)
+		err := newAPIError(action, resp.StatusCode, body)
+		log.Println(err)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return "", &SubmitError{ReferenceID: referenceID, Err: err}
+		}
+		return "", err
 	}
 
-	log.Println("Payment request created successfully")
+	log.Printf("Accepted %s with reference ID %s", action, referenceID)
 	return referenceID, nil
 }
 
+// checkCallbackURL vérifie qu'une URL de callback pointe vers l'hôte enregistré pour l'utilisateur API,
+// pour que MoMo ne l'ignore pas silencieusement.
+func (c *Client) checkCallbackURL(ctx context.Context, creds Credentials, callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+
+	u, err := neturl.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidCallbackURL, callbackURL)
+	}
+
+	registered, err := c.registeredCallbackHost(ctx, creds)
+	if err != nil {
+		return fmt.Errorf("cannot check callback URL against the registered callback host: %w", err)
+	}
+	if !strings.EqualFold(u.Hostname(), registered) {
+		return fmt.Errorf("%w: %s does not match registered host %s", ErrCallbackHostMismatch, u.Hostname(), registered)
+	}
+	return nil
+}
+
+// registeredCallbackHost renvoie l'hôte configuré pour l'utilisateur API, ou à défaut
+// celui lu une fois avec GetAPIUser. Cette lecture n'existe qu'en sandbox : ailleurs,
+// l'hôte doit être configuré.
+func (c *Client) registeredCallbackHost(ctx context.Context, creds Credentials) (string, error) {
+	if creds.CallbackHost != "" {
+		return callbackHostname(creds.CallbackHost)
+	}
+	if c.Environment != sandboxEnvironment {
+		return "", ErrCallbackHostNotConfigured
+	}
+
+	c.mu.Lock()
+	host, ok := c.callbackHosts[creds.ApiUserID]
+	c.mu.Unlock()
+	if ok {
+		return host, nil
+	}
+
+	apiUser, err := c.getAPIUser(ctx, creds.SubscriptionKey, creds.ApiUserID)
+	if err != nil {
+		return "", err
+	}
+	host, err = callbackHostname(apiUser.ProviderCallbackHost)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	if c.callbackHosts == nil {
+		c.callbackHosts = make(map[string]string)
+	}
+	c.callbackHosts[creds.ApiUserID] = host
+	c.mu.Unlock()
+	return host, nil
+}
+
 func (c *Client) GetPaymentStatus(referenceID, token string) (*RequestToPayResult, error) {
-	url := fmt.Sprintf("%s/collection/v2_0/payment/%s", baseURL, referenceID)
+	return c.getPaymentStatus(context.Background(), c.SubscriptionKey, referenceID, token)
+}
+
+func (c *Client) getPaymentStatus(ctx context.Context, subscriptionKey, referenceID, token string) (*RequestToPayResult, error) {
+	url := fmt.Sprintf("%s/collection/v2_0/payment/%s", c.endpoint(), neturl.PathEscape(referenceID))
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		log.Printf("Error creating request: %v", err)
 		return nil, err
@@ -327,12 +730,11 @@ func (c *Client) GetPaymentStatus(referenceID, token string) (*RequestToPayResul
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	req.Header.Set("X-Target-Environment", c.Environment)
-	req.Header.Set("Ocp-Apim-Subscription-Key", c.SubscriptionKey)
+	req.Header.Set("Ocp-Apim-Subscription-Key", subscriptionKey)
 	req.Header.Set("Cache-Control", "no-cache")
 
 	log.Printf("Making request to %s to get payment status", url)
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.do("get payment status", req)
 	if err != nil {
 		log.Printf("Error making request: %v", err)
 		return nil, err
@@ -347,7 +749,7 @@ func (c *Client) GetPaymentStatus(referenceID, token string) (*RequestToPayResul
 	log.Printf("Response status: %d, body: %s", resp.StatusCode, string(body))
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get payment status, status code: %d, response: %s", resp.StatusCode, string(body))
+		return nil, newAPIError("get payment status", resp.StatusCode, body)
 	}
 
 	var paymentStatus RequestToPayResult
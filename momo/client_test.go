@@ -1,10 +1,15 @@
 package momo
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/joho/godotenv"
@@ -24,8 +29,18 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// newEnvClient crée le client de l'environnement de test
+func newEnvClient(t *testing.T) *Client {
+	t.Helper()
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
 func TestGetAuthToken(t *testing.T) {
-	client := NewClient()
+	client := newEnvClient(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -48,7 +63,7 @@ func TestGetAuthToken(t *testing.T) {
 }
 
 func TestCreateAPIUser(t *testing.T) {
-	client := NewClient()
+	client := newEnvClient(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusCreated)
 	}))
@@ -62,7 +77,7 @@ func TestCreateAPIUser(t *testing.T) {
 }
 
 func TestCreateAPIKey(t *testing.T) {
-	client := NewClient()
+	client := newEnvClient(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
@@ -81,7 +96,7 @@ func TestCreateAPIKey(t *testing.T) {
 }
 
 func TestRequestToPay(t *testing.T) {
-	client := NewClient()
+	client := newEnvClient(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusAccepted)
@@ -111,3 +126,219 @@ func TestRequestToPay(t *testing.T) {
 		t.Fatalf("expected reference ID to be non-empty, got %s", referenceID)
 	}
 }
+
+func TestRequestToPayReturnsAPIError(t *testing.T) {
+	client := newEnvClient(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"code": "RESOURCE_ALREADY_EXIST", "message": "Duplicated reference id"}`))
+	}))
+	defer ts.Close()
+
+	baseURL = ts.URL
+
+	_, err := client.RequestToPay("test-token", RequestToPay{Amount: "100", Currency: "EUR", ExternalId: "123456"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusConflict || apiErr.Code != CodeResourceAlreadyExists || apiErr.Message != "Duplicated reference id" {
+		t.Fatalf("unexpected API error %+v", apiErr)
+	}
+}
+
+func TestCreateAPIUserRejectsInvalidCallbackHost(t *testing.T) {
+	client := newEnvClient(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	baseURL = ts.URL
+	for _, host := range []string{"", "   ", "ftp://example.com", "https://example.com/callback", "bad_host!"} {
+		err := client.CreateAPIUser("test-reference-id", host)
+		if !errors.Is(err, ErrInvalidCallbackHost) {
+			t.Fatalf("expected ErrInvalidCallbackHost for %q, got %v", host, err)
+		}
+	}
+}
+
+func TestGetAPIUser(t *testing.T) {
+	client := newEnvClient(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1_0/apiuser/test-reference-id" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(APIUser{
+			ProviderCallbackHost: "callback.example.com",
+			TargetEnvironment:    "sandbox",
+		})
+	}))
+	defer ts.Close()
+
+	baseURL = ts.URL
+	apiUser, err := client.GetAPIUser(context.Background(), "test-reference-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiUser.ProviderCallbackHost != "callback.example.com" {
+		t.Fatalf("expected callback host to be 'callback.example.com', got %s", apiUser.ProviderCallbackHost)
+	}
+	if apiUser.TargetEnvironment != "sandbox" {
+		t.Fatalf("expected target environment to be 'sandbox', got %s", apiUser.TargetEnvironment)
+	}
+}
+
+func TestRequestToPayRequiresCallbackHostOutsideSandbox(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{
+		Environment: "mtnuganda",
+		BaseURL:     ts.URL,
+		Collection:  Credentials{SubscriptionKey: "test-subscription-key", ApiUserID: "test-api-user-id", ApiKey: "test-api-key"},
+	})
+	request := RequestToPay{
+		Amount:      "100",
+		Currency:    "UGX",
+		ExternalId:  "123456",
+		Payer:       Payer{PartyIdType: "MSISDN", PartyId: "256772123456"},
+		CallbackURL: "https://callback.example.com/callbacks/requesttopay",
+	}
+
+	if _, err := client.RequestToPay("test-token", request); !errors.Is(err, ErrCallbackHostNotConfigured) {
+		t.Fatalf("expected ErrCallbackHostNotConfigured, got %v", err)
+	}
+	if requests != 0 {
+		t.Fatalf("expected no request to MoMo, got %d", requests)
+	}
+}
+
+func TestGetAPIUserEscapesReferenceID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/v1_0/apiuser/ref%2F..%2Fapikey" {
+			t.Errorf("expected an escaped reference ID, got %s", r.URL.EscapedPath())
+		}
+		json.NewEncoder(w).Encode(APIUser{ProviderCallbackHost: "callback.example.com"})
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{BaseURL: ts.URL})
+	if _, err := client.GetAPIUser(context.Background(), "ref/../apikey"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetPaymentStatusEscapesReferenceID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/collection/token/" {
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+			return
+		}
+		if r.URL.EscapedPath() != "/collection/v2_0/payment/..%2F..%2Fv1_0%2Faccountholder" {
+			t.Errorf("expected an escaped reference ID, got %s", r.URL.EscapedPath())
+		}
+		json.NewEncoder(w).Encode(RequestToPayResult{Status: StatusPending})
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{BaseURL: ts.URL, Collection: Credentials{SubscriptionKey: "key", ApiUserID: "user", ApiKey: "secret"}})
+	if _, err := client.Collection().GetPaymentStatus("../../v1_0/accountholder"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestToPayWithCallbackURL(t *testing.T) {
+	var callbackURL string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1_0/apiuser/test_api_user_id":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(APIUser{ProviderCallbackHost: "callback.example.com", TargetEnvironment: "sandbox"})
+		case "/collection/v1_0/requesttopay":
+			callbackURL = r.Header.Get("X-Callback-Url")
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	baseURL = ts.URL
+	client := newEnvClient(t)
+	request := RequestToPay{
+		Amount:      "100",
+		Currency:    "EUR",
+		ExternalId:  "123456",
+		Payer:       Payer{PartyIdType: "MSISDN", PartyId: "46733123453"},
+		CallbackURL: "https://callback.example.com/callbacks/requesttopay",
+	}
+
+	if _, err := client.RequestToPay("test-token", request); err != nil {
+		t.Fatal(err)
+	}
+	if callbackURL != request.CallbackURL {
+		t.Fatalf("expected X-Callback-Url to be %s, got %s", request.CallbackURL, callbackURL)
+	}
+
+	callbackURL = ""
+	request.CallbackURL = "https://other.example.com/callbacks/requesttopay"
+	if _, err := client.RequestToPay("test-token", request); !errors.Is(err, ErrCallbackHostMismatch) {
+		t.Fatalf("expected ErrCallbackHostMismatch, got %v", err)
+	}
+	if callbackURL != "" {
+		t.Fatal("expected no request to be sent for a mismatched callback URL")
+	}
+}
+
+func TestSecretsAreNotLogged(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1_0/apiuser/test-reference-id/apikey":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"apiKey": "secret-api-key"})
+		case "/collection/token/":
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "secret-access-token", ExpiresIn: 3600})
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer ts.Close()
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	client := NewClientFromConfig(Config{
+		Environment: "sandbox",
+		BaseURL:     ts.URL,
+		Collection:  Credentials{SubscriptionKey: "secret-subscription-key", ApiUserID: "test-api-user-id", ApiKey: "secret-user-key"},
+	})
+	if _, err := client.CreateAPIKey("test-reference-id"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Collection().RequestToPay(RequestToPay{
+		Amount:     "100",
+		Currency:   "EUR",
+		ExternalId: "123456",
+		Payer:      Payer{PartyIdType: "MSISDN", PartyId: "46733123453"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"secret-api-key", "secret-access-token", "secret-subscription-key", "secret-user-key"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("expected %s not to be logged", secret)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package momo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrCredentialsNotFound est renvoyée quand aucune entrée ne correspond dans le store
+var ErrCredentialsNotFound = errors.New("credentials not found")
+
+// CredentialStore conserve les identifiants par environnement et par produit.
+type CredentialStore interface {
+	Get(environment string, product Product) (Credentials, error)
+	Put(environment string, product Product, creds Credentials) error
+	Delete(environment string, product Product) error
+	List() ([]CredentialEntry, error)
+}
+
+// Structure pour une entrée du store d'identifiants
+type CredentialEntry struct {
+	Environment string      `json:"environment"`
+	Product     Product     `json:"product"`
+	Credentials Credentials `json:"credentials"`
+}
+
+// Paramètres scrypt recommandés pour une utilisation interactive
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltSize     = 16
+
+	credentialFileVersion = 1
+)
+
+// Structure du fichier chiffré sur disque
+type encryptedCredentialFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// EncryptedFileStore est un CredentialStore chiffré en AES-256-GCM,
+// avec une clé dérivée par scrypt d'une passphrase ou d'un fichier de clé.
+type EncryptedFileStore struct {
+	path   string
+	secret []byte
+	mu     sync.Mutex
+}
+
+var _ CredentialStore = (*EncryptedFileStore)(nil)
+
+func NewEncryptedFileStore(path, passphrase string) (*EncryptedFileStore, error) {
+	if passphrase == "" {
+		return nil, errors.New("credential store passphrase is empty")
+	}
+	return &EncryptedFileStore{path: path, secret: []byte(passphrase)}, nil
+}
+
+// NewEncryptedFileStoreFromKeyFile utilise le contenu d'un fichier de clé comme secret.
+func NewEncryptedFileStoreFromKeyFile(path, keyFile string) (*EncryptedFileStore, error) {
+	secret, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptedFileStore(path, strings.TrimSpace(string(secret)))
+}
+
+func (s *EncryptedFileStore) Path() string {
+	return s.path
+}
+
+func (s *EncryptedFileStore) Get(environment string, product Product) (Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, _, err := s.load()
+	if err != nil {
+		return Credentials{}, err
+	}
+	for _, entry := range entries {
+		if entry.Environment == environment && entry.Product == product {
+			return entry.Credentials, nil
+		}
+	}
+	return Credentials{}, fmt.Errorf("%w for %s/%s", ErrCredentialsNotFound, environment, product)
+}
+
+func (s *EncryptedFileStore) Put(environment string, product Product, creds Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, salt, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, entry := range entries {
+		if entry.Environment == environment && entry.Product == product {
+			entries[i].Credentials = creds
+			replaced = true
+		}
+	}
+	if !replaced {
+		entries = append(entries, CredentialEntry{Environment: environment, Product: product, Credentials: creds})
+	}
+	return s.save(entries, salt)
+}
+
+func (s *EncryptedFileStore) Delete(environment string, product Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, salt, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.Environment != environment || entry.Product != product {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == len(entries) {
+		return fmt.Errorf("%w for %s/%s", ErrCredentialsNotFound, environment, product)
+	}
+	return s.save(kept, salt)
+}
+
+func (s *EncryptedFileStore) List() ([]CredentialEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Environment != entries[j].Environment {
+			return entries[i].Environment < entries[j].Environment
+		}
+		return entries[i].Product < entries[j].Product
+	})
+	return entries, nil
+}
+
+// load déchiffre le fichier. Un fichier absent correspond à un store vide.
+func (s *EncryptedFileStore) load() ([]CredentialEntry, []byte, error) {
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file encryptedCredentialFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, nil, fmt.Errorf("invalid credential store %s: %w", s.path, err)
+	}
+	if file.Version != credentialFileVersion {
+		return nil, nil, fmt.Errorf("unsupported credential store version %d", file.Version)
+	}
+
+	gcm, err := s.cipher(file.Salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, nil, errors.New("cannot decrypt credential store: wrong passphrase or corrupted file")
+	}
+
+	var entries []CredentialEntry
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, nil, err
+	}
+	return entries, file.Salt, nil
+}
+
+// save chiffre les entrées et remplace le fichier de manière atomique.
+func (s *EncryptedFileStore) save(entries []CredentialEntry, salt []byte) error {
+	if salt == nil {
+		salt = make([]byte, saltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return err
+		}
+	}
+
+	gcm, err := s.cipher(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(encryptedCredentialFile{
+		Version: credentialFileVersion,
+		Salt:    salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".credentials-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *EncryptedFileStore) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(s.secret, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ConfigFromStore construit une configuration à partir des identifiants d'un environnement.
+// Les produits absents du store restent vides.
+func ConfigFromStore(store CredentialStore, environment string) (Config, error) {
+	entries, err := store.List()
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Config{Environment: environment}
+	for _, entry := range entries {
+		if entry.Environment != environment {
+			continue
+		}
+		switch entry.Product {
+		case ProductCollection:
+			cfg.Collection = entry.Credentials
+		case ProductDisbursement:
+			cfg.Disbursement = entry.Credentials
+		case ProductRemittance:
+			cfg.Remittance = entry.Credentials
+		}
+	}
+	return cfg, nil
+}
+
+func NewClientFromStore(store CredentialStore, environment string) (*Client, error) {
+	cfg, err := ConfigFromStore(store, environment)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientFromConfig(cfg), nil
+}
+
+// DefaultCredentialStorePath renvoie le chemin du store : MOMO_CREDENTIALS_FILE, ou à défaut
+// $HOME/.mtn-momo-api/credentials.enc, où momo-cli creds l'enregistre.
+func DefaultCredentialStorePath() (string, error) {
+	if path := os.Getenv("MOMO_CREDENTIALS_FILE"); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".mtn-momo-api", "credentials.enc"), nil
+}
+
+// CredentialStoreFromEnv ouvre le store désigné par DefaultCredentialStorePath, déverrouillé
+// par MOMO_CREDENTIALS_KEY_FILE ou MOMO_CREDENTIALS_PASSPHRASE. Il renvoie nil si
+// MOMO_CREDENTIALS_FILE n'est pas défini et que le store par défaut n'existe pas.
+func CredentialStoreFromEnv() (*EncryptedFileStore, error) {
+	path, err := DefaultCredentialStorePath()
+	if err != nil {
+		return nil, err
+	}
+	if os.Getenv("MOMO_CREDENTIALS_FILE") == "" {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+	}
+	if keyFile := os.Getenv("MOMO_CREDENTIALS_KEY_FILE"); keyFile != "" {
+		return NewEncryptedFileStoreFromKeyFile(path, keyFile)
+	}
+	store, err := NewEncryptedFileStore(path, os.Getenv("MOMO_CREDENTIALS_PASSPHRASE"))
+	if err != nil {
+		return nil, fmt.Errorf("credential store %s: %w, set MOMO_CREDENTIALS_PASSPHRASE or MOMO_CREDENTIALS_KEY_FILE", path, err)
+	}
+	return store, nil
+}
+
+// FillConfigFromStore complète les champs d'identifiants vides de cfg avec ceux enregistrés
+// dans store pour cfg.Environment. Les identifiants déjà présents dans cfg sont conservés.
+func FillConfigFromStore(cfg Config, store CredentialStore) (Config, error) {
+	stored, err := ConfigFromStore(store, cfg.Environment)
+	if err != nil {
+		return cfg, err
+	}
+	for _, pair := range []struct{ dst, src *Credentials }{
+		{&cfg.Collection, &stored.Collection},
+		{&cfg.Disbursement, &stored.Disbursement},
+		{&cfg.Remittance, &stored.Remittance},
+	} {
+		fillField(&pair.dst.SubscriptionKey, pair.src.SubscriptionKey)
+		fillField(&pair.dst.ApiUserID, pair.src.ApiUserID)
+		fillField(&pair.dst.ApiKey, pair.src.ApiKey)
+	}
+	return cfg, nil
+}
+
+func fillField(dst *string, src string) {
+	if *dst == "" {
+		*dst = src
+	}
+}
+
+// mergeStoreConfig complète cfg avec les identifiants du store configuré par l'environnement,
+// sans remplacer ceux que cfg définit déjà.
+func mergeStoreConfig(cfg Config) (Config, error) {
+	store, err := CredentialStoreFromEnv()
+	if err != nil {
+		return cfg, fmt.Errorf("error opening credential store: %w", err)
+	}
+	if store == nil {
+		return cfg, nil
+	}
+
+	merged, err := FillConfigFromStore(cfg, store)
+	if err != nil {
+		return cfg, fmt.Errorf("error loading credential store %s: %w", store.Path(), err)
+	}
+	return merged, nil
+}
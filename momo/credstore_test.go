@@ -0,0 +1,135 @@
+package momo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	store, err := NewEncryptedFileStore(path, "test-passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds := Credentials{SubscriptionKey: "test-subscription-key", ApiUserID: "test-api-user-id", ApiKey: "test-api-key"}
+	if err := store.Put("sandbox", ProductCollection, creds); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("sandbox", ProductDisbursement, Credentials{ApiKey: "disbursement-key"}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "test-api-key") {
+		t.Fatal("expected API key to be encrypted on disk")
+	}
+
+	got, err := store.Get("sandbox", ProductCollection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != creds {
+		t.Fatalf("expected %+v, got %+v", creds, got)
+	}
+
+	cfg, err := ConfigFromStore(store, "sandbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Collection != creds || cfg.Disbursement.ApiKey != "disbursement-key" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	wrong, _ := NewEncryptedFileStore(path, "wrong-passphrase")
+	if _, err := wrong.List(); err == nil {
+		t.Fatal("expected an error with a wrong passphrase")
+	}
+
+	if err := store.Delete("sandbox", ProductCollection); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("sandbox", ProductCollection); !errors.Is(err, ErrCredentialsNotFound) {
+		t.Fatalf("expected ErrCredentialsNotFound, got %v", err)
+	}
+	entries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Product != ProductDisbursement {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
+
+func TestCredentialStoreFromEnvUsesDefaultPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("MOMO_CREDENTIALS_FILE", "")
+	t.Setenv("MOMO_CREDENTIALS_KEY_FILE", "")
+	t.Setenv("MOMO_CREDENTIALS_PASSPHRASE", "")
+
+	if store, err := CredentialStoreFromEnv(); err != nil || store != nil {
+		t.Fatalf("expected no store without a default file, got %v, %v", store, err)
+	}
+
+	path := filepath.Join(home, ".mtn-momo-api", "credentials.enc")
+	store, err := NewEncryptedFileStore(path, "test-passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := Credentials{SubscriptionKey: "test-subscription-key", ApiUserID: "test-api-user-id", ApiKey: "test-api-key"}
+	if err := store.Put("sandbox", ProductCollection, creds); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mergeStoreConfig(Config{Environment: "sandbox"}); err == nil {
+		t.Fatal("expected an error for a locked store")
+	}
+
+	t.Setenv("MOMO_CREDENTIALS_PASSPHRASE", "test-passphrase")
+	cfg, err := mergeStoreConfig(Config{Environment: "sandbox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Collection != creds {
+		t.Fatalf("expected %+v, got %+v", creds, cfg.Collection)
+	}
+}
+
+func TestMergeStoreConfigKeepsExplicitCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	t.Setenv("MOMO_CREDENTIALS_FILE", path)
+	t.Setenv("MOMO_CREDENTIALS_KEY_FILE", "")
+	t.Setenv("MOMO_CREDENTIALS_PASSPHRASE", "test-passphrase")
+
+	store, err := NewEncryptedFileStore(path, "test-passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := Credentials{SubscriptionKey: "stored-subscription-key", ApiUserID: "stored-api-user-id", ApiKey: "stored-api-key"}
+	if err := store.Put("sandbox", ProductCollection, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	// Les identifiants déjà définis sont conservés, seuls les champs vides viennent du store
+	cfg, err := mergeStoreConfig(Config{Environment: "sandbox", Collection: Credentials{ApiUserID: "env-api-user-id", ApiKey: "env-api-key"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Credentials{SubscriptionKey: "stored-subscription-key", ApiUserID: "env-api-user-id", ApiKey: "env-api-key"}
+	if cfg.Collection != expected {
+		t.Fatalf("expected %+v, got %+v", expected, cfg.Collection)
+	}
+
+	// Un store illisible fait échouer NewClient au lieu de continuer sans lui
+	t.Setenv("MOMO_CREDENTIALS_PASSPHRASE", "wrong-passphrase")
+	if client, err := NewClient(); err == nil || client != nil {
+		t.Fatalf("expected an error and no client, got %v, %v", client, err)
+	}
+}
@@ -1,12 +1,71 @@
 package momo
 
 import (
-	"log"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCallbackHost est renvoyée quand un providerCallbackHost est vide ou invalide
+var ErrInvalidCallbackHost = errors.New("invalid callback host")
+
+// ErrInvalidCallbackURL est renvoyée quand une URL de callback n'est pas une URL http(s) absolue
+var ErrInvalidCallbackURL = errors.New("invalid callback URL")
+
+// ErrCallbackHostMismatch est renvoyée quand une URL de callback ne pointe pas vers l'hôte
+// enregistré pour l'utilisateur API
+var ErrCallbackHostMismatch = errors.New("callback URL host does not match the registered callback host")
 
-	"github.com/gin-gonic/gin"
+// ErrCallbackHostNotConfigured est renvoyée hors sandbox quand une URL de callback est donnée
+// sans callback_host configuré, car l'hôte enregistré ne peut être lu qu'en sandbox
+var ErrCallbackHostNotConfigured = errors.New("callback_host must be set for the API user outside the sandbox")
+
+// Codes d'erreur renvoyés par MoMo
+const (
+	CodePayerNotFound         = "PAYER_NOT_FOUND"
+	CodePayeeNotFound         = "PAYEE_NOT_FOUND"
+	CodeResourceNotFound      = "RESOURCE_NOT_FOUND"
+	CodeResourceAlreadyExists = "RESOURCE_ALREADY_EXIST"
+	CodeInternalProcessing    = "INTERNAL_PROCESSING_ERROR"
+	CodeServiceUnavailable    = "SERVICE_UNAVAILABLE"
 )
 
-func HandleError(c *gin.Context, statusCode int, err interface{}) {
-	log.Printf("Error: %v", err)
-	c.JSON(statusCode, gin.H{"error": err})
+// APIError est renvoyée quand MoMo répond avec un statut inattendu. Code et Message sont
+// lus dans le corps de la réponse quand il suit le format d'erreur de MoMo.
+type APIError struct {
+	Operation  string
+	StatusCode int
+	Code       string
+	Message    string
+	Body       string
+}
+
+func newAPIError(operation string, statusCode int, body []byte) *APIError {
+	err := &APIError{Operation: operation, StatusCode: statusCode, Body: string(body)}
+	var reason ErrorReason
+	if json.Unmarshal(body, &reason) == nil {
+		err.Code = reason.Code
+		err.Message = reason.Message
+	}
+	return err
+}
+
+// SubmitError est renvoyée quand l'issue d'une opération envoyée à MoMo est inconnue :
+// délai dépassé, connexion interrompue ou erreur 5xx. MoMo a pu accepter l'opération sous
+// ReferenceID ; son statut doit être lu avant de la renvoyer.
+type SubmitError struct {
+	ReferenceID string
+	Err         error
+}
+
+func (e *SubmitError) Error() string {
+	return fmt.Sprintf("outcome of %s unknown: %v", e.ReferenceID, e.Err)
+}
+
+func (e *SubmitError) Unwrap() error {
+	return e.Err
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("failed to %s, status code: %d, response: %s", e.Operation, e.StatusCode, e.Body)
 }
@@ -0,0 +1,30 @@
+package momo
+
+import "time"
+
+// expiryQueue garde des clés dans leur ordre d'expiration. Toutes les clés ayant la même
+// durée de vie, l'ordre d'insertion suffit et l'éviction ne parcourt que les clés expirées.
+type expiryQueue struct {
+	entries []expiryEntry
+}
+
+type expiryEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+func (q *expiryQueue) push(key string, expiresAt time.Time) {
+	q.entries = append(q.entries, expiryEntry{key: key, expiresAt: expiresAt})
+}
+
+// evict appelle expire pour chaque clé expirée à now, avec l'échéance enregistrée.
+// Une clé ajoutée plusieurs fois est présentée à chaque échéance : à expire de vérifier
+// qu'elle n'a pas été prolongée depuis.
+func (q *expiryQueue) evict(now time.Time, expire func(key string, expiresAt time.Time)) {
+	for len(q.entries) > 0 && !now.Before(q.entries[0].expiresAt) {
+		entry := q.entries[0]
+		q.entries[0] = expiryEntry{}
+		q.entries = q.entries[1:]
+		expire(entry.key, entry.expiresAt)
+	}
+}
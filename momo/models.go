@@ -1,10 +1,57 @@
 package momo
 
+import (
+	"encoding/json"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
 type Client struct {
 	ApiKey          string
 	ApiUserID       string
 	SubscriptionKey string
 	Environment     string
+	BaseURL         string
+
+	// Pending enregistre les opérations acceptées pour les rapprocher de leurs callbacks
+	Pending PendingStore
+	// Metrics reçoit les mesures des appels à MoMo, s'il est défini
+	Metrics Metrics
+	// TracerProvider crée les spans du client. Par défaut, le fournisseur global d'OpenTelemetry
+	TracerProvider trace.TracerProvider
+	// PropagateTrace envoie le contexte de trace (traceparent, tracestate) à MoMo avec
+	// chaque appel. Désactivé par défaut pour ne pas exposer les traces internes à une API externe
+	PropagateTrace bool
+
+	config        Config
+	mu            sync.Mutex
+	products      map[Product]*ProductClient
+	callbackHosts map[string]string
+	calls         callLog
+}
+
+// Structure pour les identifiants d'un produit MoMo
+type Credentials struct {
+	SubscriptionKey string `json:"subscriptionKey" mapstructure:"subscription_key"`
+	ApiUserID       string `json:"apiUserId" mapstructure:"api_user_id"`
+	ApiKey          string `json:"apiKey" mapstructure:"api_key"`
+	CallbackHost    string `json:"callbackHost,omitempty" mapstructure:"callback_host"`
+}
+
+// Structure pour la configuration du client, avec des identifiants par produit
+type Config struct {
+	Environment  string      `json:"environment" mapstructure:"environment"`
+	BaseURL      string      `json:"baseUrl" mapstructure:"base_url"`
+	Collection   Credentials `json:"collection" mapstructure:"collection"`
+	Disbursement Credentials `json:"disbursement" mapstructure:"disbursement"`
+	Remittance   Credentials `json:"remittance" mapstructure:"remittance"`
+}
+
+// Structure pour un utilisateur API provisionné
+type APIUser struct {
+	ProviderCallbackHost string `json:"providerCallbackHost"`
+	TargetEnvironment    string `json:"targetEnvironment"`
 }
 
 // Structure pour le token d'authentification
@@ -36,6 +83,18 @@ type ErrorReason struct {
 	Message string `json:"message"`
 }
 
+// UnmarshalJSON accepte aussi la forme courte où MoMo renvoie seulement le code, ex. "APPROVAL_REJECTED".
+func (r *ErrorReason) UnmarshalJSON(data []byte) error {
+	var code string
+	if err := json.Unmarshal(data, &code); err == nil {
+		r.Code = code
+		return nil
+	}
+
+	type errorReason ErrorReason
+	return json.Unmarshal(data, (*errorReason)(r))
+}
+
 // Structure pour une requête de paiement
 type RequestToPay struct {
 	Amount       string `json:"amount"`
@@ -44,6 +103,26 @@ type RequestToPay struct {
 	Payer        Payer  `json:"payer"`
 	PayerMessage string `json:"payerMessage"`
 	PayeeNote    string `json:"payeeNote"`
+
+	// CallbackURL est envoyé dans l'en-tête X-Callback-Url, pas dans le corps
+	CallbackURL string `json:"-"`
+	// CallerID identifie l'appelant à l'origine de la demande. Il n'est pas envoyé à MoMo.
+	CallerID string `json:"-"`
+}
+
+// Structure pour un transfert (disbursement ou remittance)
+type Transfer struct {
+	Amount       string `json:"amount"`
+	Currency     string `json:"currency"`
+	ExternalId   string `json:"externalId"`
+	Payee        Party  `json:"payee"`
+	PayerMessage string `json:"payerMessage"`
+	PayeeNote    string `json:"payeeNote"`
+
+	// CallbackURL est envoyé dans l'en-tête X-Callback-Url, pas dans le corps
+	CallbackURL string `json:"-"`
+	// CallerID identifie l'appelant à l'origine de la demande. Il n'est pas envoyé à MoMo.
+	CallerID string `json:"-"`
 }
 
 type Payer struct {
@@ -51,12 +130,48 @@ type Payer struct {
 	PartyId     string `json:"partyId"`
 }
 
+// Party désigne un payeur ou un bénéficiaire
+type Party = Payer
+
+// Statuts renvoyés par MoMo pour une transaction
+const (
+	StatusPending    = "PENDING"
+	StatusSuccessful = "SUCCESSFUL"
+	StatusFailed     = "FAILED"
+)
+
+// IsFinalStatus indique si une transaction ne changera plus de statut.
+func IsFinalStatus(status string) bool {
+	return status == StatusSuccessful || status == StatusFailed
+}
+
 // Structure pour les résultats de paiement
 type RequestToPayResult struct {
-	ReferenceId string `json:"referenceId"`
-	Status      string `json:"status"`
-	//FinancialTransactionId string      `json:"financialTransactionId,omitempty"`
-	//ExternalId             string      `json:"externalId,omitempty"`
-	//Payer                  Payer       `json:"payer,omitempty"`
-	//Reason                 ErrorReason `json:"reason,omitempty"`
+	ReferenceId            string       `json:"referenceId,omitempty"`
+	Status                 string       `json:"status"`
+	Amount                 string       `json:"amount,omitempty"`
+	Currency               string       `json:"currency,omitempty"`
+	FinancialTransactionId string       `json:"financialTransactionId,omitempty"`
+	ExternalId             string       `json:"externalId,omitempty"`
+	Payer                  Payer        `json:"payer"`
+	PayerMessage           string       `json:"payerMessage,omitempty"`
+	PayeeNote              string       `json:"payeeNote,omitempty"`
+	Reason                 *ErrorReason `json:"reason,omitempty"`
+}
+
+// Structure pour les résultats de retrait, identique à celle d'un paiement
+type RequestToWithdrawResult = RequestToPayResult
+
+// Structure pour les résultats de transfert, de dépôt et de remboursement
+type TransferResult struct {
+	ReferenceId            string       `json:"referenceId,omitempty"`
+	Status                 string       `json:"status"`
+	Amount                 string       `json:"amount,omitempty"`
+	Currency               string       `json:"currency,omitempty"`
+	FinancialTransactionId string       `json:"financialTransactionId,omitempty"`
+	ExternalId             string       `json:"externalId,omitempty"`
+	Payee                  Party        `json:"payee"`
+	PayerMessage           string       `json:"payerMessage,omitempty"`
+	PayeeNote              string       `json:"payeeNote,omitempty"`
+	Reason                 *ErrorReason `json:"reason,omitempty"`
 }
@@ -0,0 +1,117 @@
+package momo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Structure pour une opération acceptée par MoMo et en attente de son résultat
+type PendingRequest struct {
+	ReferenceID string       `json:"referenceId"`
+	Type        CallbackType `json:"type"`
+	Product     Product      `json:"product"`
+	Amount      string       `json:"amount"`
+	Currency    string       `json:"currency"`
+	ExternalId  string       `json:"externalId"`
+	PartyId     string       `json:"partyId"`
+	CallerID    string       `json:"callerId,omitempty"`
+	TraceParent string       `json:"traceParent,omitempty"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// PendingStore conserve les opérations en attente, indexées par référence.
+type PendingStore interface {
+	Add(ctx context.Context, pending PendingRequest) error
+	Get(ctx context.Context, referenceID string) (PendingRequest, bool, error)
+	Remove(ctx context.Context, referenceID string) error
+}
+
+// Durée par défaut pendant laquelle MemoryPendingStore conserve une opération
+const defaultPendingTTL = 24 * time.Hour
+
+// MemoryPendingStore est un PendingStore en mémoire, limité à un seul processus.
+// Une opération dont le statut final n'a pas été reçu est oubliée après TTL.
+type MemoryPendingStore struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	pending map[string]memoryPending
+	expiry  expiryQueue
+}
+
+type memoryPending struct {
+	request   PendingRequest
+	expiresAt time.Time
+}
+
+var _ PendingStore = (*MemoryPendingStore)(nil)
+
+func NewMemoryPendingStore() *MemoryPendingStore {
+	return &MemoryPendingStore{TTL: defaultPendingTTL, pending: make(map[string]memoryPending)}
+}
+
+func (s *MemoryPendingStore) Add(ctx context.Context, pending PendingRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.evict(now)
+	expiresAt := now.Add(s.TTL)
+	s.pending[pending.ReferenceID] = memoryPending{request: pending, expiresAt: expiresAt}
+	s.expiry.push(pending.ReferenceID, expiresAt)
+	return nil
+}
+
+func (s *MemoryPendingStore) Get(ctx context.Context, referenceID string) (PendingRequest, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict(time.Now())
+	pending, ok := s.pending[referenceID]
+	return pending.request, ok, nil
+}
+
+func (s *MemoryPendingStore) Remove(ctx context.Context, referenceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, referenceID)
+	return nil
+}
+
+func (s *MemoryPendingStore) evict(now time.Time) {
+	s.expiry.evict(now, func(referenceID string, expiresAt time.Time) {
+		if pending, ok := s.pending[referenceID]; ok && pending.expiresAt.Equal(expiresAt) {
+			delete(s.pending, referenceID)
+		}
+	})
+}
+
+// pendingResult lit auprès de MoMo le statut et le résultat actuels d'une opération en attente.
+func (c *Client) pendingResult(ctx context.Context, pending PendingRequest) (string, interface{}, error) {
+	p := c.product(pending.Product)
+	token, err := p.token(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	subscriptionKey := p.Credentials.SubscriptionKey
+
+	switch pending.Type {
+	case CallbackRequestToPay:
+		result, err := c.getPaymentStatus(ctx, subscriptionKey, pending.ReferenceID, token)
+		if err != nil {
+			return "", nil, err
+		}
+		return result.Status, result, nil
+	case CallbackWithdrawal:
+		result, err := c.getWithdrawalStatus(ctx, subscriptionKey, pending.ReferenceID, token)
+		if err != nil {
+			return "", nil, err
+		}
+		return result.Status, result, nil
+	default:
+		result, err := c.getTransferResult(ctx, pending.Product, pending.Type, subscriptionKey, pending.ReferenceID, token)
+		if err != nil {
+			return "", nil, err
+		}
+		return result.Status, result, nil
+	}
+}
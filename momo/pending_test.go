@@ -0,0 +1,31 @@
+package momo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryPendingStoreExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryPendingStore()
+	store.TTL = 20 * time.Millisecond
+
+	if err := store.Add(ctx, PendingRequest{ReferenceID: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if err := store.Add(ctx, PendingRequest{ReferenceID: "new"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, _ := store.Get(ctx, "old"); ok {
+		t.Fatal("expected the expired request to be evicted")
+	}
+	if _, ok, _ := store.Get(ctx, "new"); !ok {
+		t.Fatal("expected the recent request to be kept")
+	}
+	if len(store.pending) != 1 {
+		t.Fatalf("expected one request in memory, got %d", len(store.pending))
+	}
+}
@@ -0,0 +1,351 @@
+package momo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Product identifie un produit MoMo (collection, disbursement ou remittance)
+type Product string
+
+const (
+	ProductCollection   Product = "collection"
+	ProductDisbursement Product = "disbursement"
+	ProductRemittance   Product = "remittance"
+)
+
+// ParseProduct convertit un nom de produit en Product.
+func ParseProduct(name string) (Product, error) {
+	switch product := Product(strings.ToLower(strings.TrimSpace(name))); product {
+	case ProductCollection, ProductDisbursement, ProductRemittance:
+		return product, nil
+	default:
+		return "", fmt.Errorf("unknown product %q", name)
+	}
+}
+
+// Marge retirée de la durée de vie d'un token pour le renouveler avant son expiration
+const tokenExpiryMargin = 30 * time.Second
+
+// ProductClient est un client limité à un produit, avec ses propres identifiants
+// et son propre cache de token.
+type ProductClient struct {
+	Product     Product
+	Credentials Credentials
+
+	client *Client
+	tokens *tokenCache
+	ctx    context.Context
+}
+
+type CollectionClient struct {
+	*ProductClient
+}
+
+type DisbursementClient struct {
+	*ProductClient
+}
+
+type RemittanceClient struct {
+	*ProductClient
+}
+
+func (c *Client) Collection() *CollectionClient {
+	return &CollectionClient{c.product(ProductCollection)}
+}
+
+func (c *Client) Disbursement() *DisbursementClient {
+	return &DisbursementClient{c.product(ProductDisbursement)}
+}
+
+func (c *Client) Remittance() *RemittanceClient {
+	return &RemittanceClient{c.product(ProductRemittance)}
+}
+
+func (c *Client) product(product Product) *ProductClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.products == nil {
+		c.products = make(map[Product]*ProductClient)
+	}
+	if p, ok := c.products[product]; ok {
+		return p
+	}
+
+	p := &ProductClient{
+		Product:     product,
+		Credentials: c.credentials(product),
+		client:      c,
+		tokens:      &tokenCache{},
+	}
+	c.products[product] = p
+	return p
+}
+
+func (c *Client) credentials(product Product) Credentials {
+	switch product {
+	case ProductDisbursement:
+		return c.config.Disbursement
+	case ProductRemittance:
+		return c.config.Remittance
+	default:
+		return c.collectionCredentials()
+	}
+}
+
+// WithContext renvoie une copie du client qui utilise ctx pour ses appels à MoMo :
+// annulation, délai et span parent du traçage. Le cache de token reste partagé.
+func (c *CollectionClient) WithContext(ctx context.Context) *CollectionClient {
+	return &CollectionClient{c.withContext(ctx)}
+}
+
+func (c *DisbursementClient) WithContext(ctx context.Context) *DisbursementClient {
+	return &DisbursementClient{c.withContext(ctx)}
+}
+
+func (c *RemittanceClient) WithContext(ctx context.Context) *RemittanceClient {
+	return &RemittanceClient{c.withContext(ctx)}
+}
+
+func (p *ProductClient) withContext(ctx context.Context) *ProductClient {
+	copy := *p
+	copy.ctx = ctx
+	return &copy
+}
+
+func (p *ProductClient) context() context.Context {
+	if p.ctx != nil {
+		return p.ctx
+	}
+	return context.Background()
+}
+
+// GetAuthToken demande toujours un nouveau token au produit, sans passer par le cache.
+func (p *ProductClient) GetAuthToken() (*AuthToken, error) {
+	return p.getAuthToken(p.context())
+}
+
+func (p *ProductClient) getAuthToken(ctx context.Context) (token *AuthToken, err error) {
+	ctx, span := p.startSpan(ctx, "GetAuthToken", "")
+	defer func() { endSpan(span, err) }()
+
+	token, err = p.client.getAuthToken(ctx, p.Product, p.Credentials)
+	if p.client.Metrics != nil {
+		p.client.Metrics.ObserveTokenRefresh(p.Product, err)
+	}
+	return token, err
+}
+
+// Token renvoie le token d'accès en cache et le renouvelle s'il a expiré.
+func (p *ProductClient) Token() (string, error) {
+	return p.token(p.context())
+}
+
+func (p *ProductClient) token(ctx context.Context) (string, error) {
+	return p.tokens.get(func() (*AuthToken, error) {
+		return p.getAuthToken(ctx)
+	})
+}
+
+// TokenExpiry renvoie la date d'expiration du token en cache, ou zéro s'il n'y en a pas.
+func (p *ProductClient) TokenExpiry() time.Time {
+	p.tokens.mu.Lock()
+	defer p.tokens.mu.Unlock()
+	return p.tokens.expiresAt
+}
+
+// CachedToken renvoie le token en cache et son expiration, ou nil s'il n'y en a pas.
+func (p *ProductClient) CachedToken() (*AuthToken, time.Time) {
+	p.tokens.mu.Lock()
+	defer p.tokens.mu.Unlock()
+	return p.tokens.token, p.tokens.expiresAt
+}
+
+// RestoreToken remet en cache un token obtenu plus tôt, par exemple conservé sur disque
+// entre deux exécutions. Un token expiré ou vide est ignoré.
+func (p *ProductClient) RestoreToken(token *AuthToken, expiresAt time.Time) {
+	if token == nil || token.AccessToken == "" || !time.Now().Before(expiresAt) {
+		return
+	}
+	p.tokens.mu.Lock()
+	defer p.tokens.mu.Unlock()
+	p.tokens.token = token
+	p.tokens.expiresAt = expiresAt
+}
+
+// InvalidateToken vide le cache, par exemple après une réponse 401.
+func (p *ProductClient) InvalidateToken() {
+	p.tokens.mu.Lock()
+	defer p.tokens.mu.Unlock()
+	p.tokens.token = nil
+	p.tokens.expiresAt = time.Time{}
+}
+
+func (p *ProductClient) GetAccountBalance() (balance *Balance, err error) {
+	ctx, span := p.startSpan(p.context(), "GetAccountBalance", "")
+	defer func() { endSpan(span, err) }()
+
+	token, err := p.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.getAccountBalance(ctx, p.Product, p.Credentials.SubscriptionKey, token, "")
+}
+
+// GetAccountBalanceInCurrency renvoie le solde du compte converti dans la devise demandée.
+func (p *ProductClient) GetAccountBalanceInCurrency(currency string) (balance *Balance, err error) {
+	ctx, span := p.startSpan(p.context(), "GetAccountBalanceInCurrency", "")
+	defer func() { endSpan(span, err) }()
+
+	if !IsCurrencyCode(currency) {
+		v := &ValidationError{}
+		v.add("currency", "must be an ISO 4217 currency code")
+		return nil, v
+	}
+	token, err := p.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.getAccountBalance(ctx, p.Product, p.Credentials.SubscriptionKey, token, currency)
+}
+
+// ValidateAccountHolder indique si le titulaire du compte désigné par party est actif
+// chez MoMo. La partie est validée avant l'appel.
+func (p *ProductClient) ValidateAccountHolder(party Party) (active bool, err error) {
+	ctx, span := p.startSpan(p.context(), "ValidateAccountHolder", "")
+	defer func() { endSpan(span, err) }()
+
+	v := &ValidationError{}
+	validateParty(v, "accountHolder", party)
+	if err := v.orNil(); err != nil {
+		return false, err
+	}
+
+	token, err := p.token(ctx)
+	if err != nil {
+		return false, err
+	}
+	return p.client.validateAccountHolder(ctx, p.Product, p.Credentials.SubscriptionKey, token, party)
+}
+
+func (c *CollectionClient) RequestToPay(request RequestToPay) (referenceID string, err error) {
+	ctx, span := c.startSpan(c.context(), "RequestToPay", "")
+	defer func() { endSpan(span, err, referenceAttribute(referenceID)) }()
+
+	if err := c.client.checkCallbackURL(ctx, c.Credentials, request.CallbackURL); err != nil {
+		return "", err
+	}
+	token, err := c.token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.client.requestToPay(ctx, c.Credentials.SubscriptionKey, token, request)
+}
+
+func (c *CollectionClient) RequestToWithdraw(request RequestToPay) (referenceID string, err error) {
+	ctx, span := c.startSpan(c.context(), "RequestToWithdraw", "")
+	defer func() { endSpan(span, err, referenceAttribute(referenceID)) }()
+
+	if err := c.client.checkCallbackURL(ctx, c.Credentials, request.CallbackURL); err != nil {
+		return "", err
+	}
+	token, err := c.token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.client.requestToWithdraw(ctx, c.Credentials.SubscriptionKey, token, request)
+}
+
+func (c *CollectionClient) GetPaymentStatus(referenceID string) (result *RequestToPayResult, err error) {
+	ctx, span := c.startSpan(c.context(), "GetPaymentStatus", referenceID)
+	defer func() { endSpan(span, err, paymentStatusAttribute(result)) }()
+
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.getPaymentStatus(ctx, c.Credentials.SubscriptionKey, referenceID, token)
+}
+
+func (c *CollectionClient) GetWithdrawalStatus(referenceID string) (result *RequestToWithdrawResult, err error) {
+	ctx, span := c.startSpan(c.context(), "GetWithdrawalStatus", referenceID)
+	defer func() { endSpan(span, err, paymentStatusAttribute(result)) }()
+
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.getWithdrawalStatus(ctx, c.Credentials.SubscriptionKey, referenceID, token)
+}
+
+func (c *DisbursementClient) Transfer(request Transfer) (string, error) {
+	return c.transfer(request)
+}
+
+func (c *RemittanceClient) Transfer(request Transfer) (string, error) {
+	return c.transfer(request)
+}
+
+func (p *ProductClient) transfer(request Transfer) (referenceID string, err error) {
+	ctx, span := p.startSpan(p.context(), "Transfer", "")
+	defer func() { endSpan(span, err, referenceAttribute(referenceID)) }()
+
+	if err := p.client.checkCallbackURL(ctx, p.Credentials, request.CallbackURL); err != nil {
+		return "", err
+	}
+	token, err := p.token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return p.client.transfer(ctx, p.Product, p.Credentials.SubscriptionKey, token, request)
+}
+
+func (c *DisbursementClient) GetTransferStatus(referenceID string) (*TransferResult, error) {
+	return c.getTransferResult(CallbackTransfer, referenceID)
+}
+
+func (c *RemittanceClient) GetTransferStatus(referenceID string) (*TransferResult, error) {
+	return c.getTransferResult(CallbackTransfer, referenceID)
+}
+
+func (p *ProductClient) getTransferResult(operation CallbackType, referenceID string) (result *TransferResult, err error) {
+	ctx, span := p.startSpan(p.context(), "GetTransferStatus", referenceID)
+	defer func() { endSpan(span, err, transferStatusAttribute(result)) }()
+
+	token, err := p.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.getTransferResult(ctx, p.Product, operation, p.Credentials.SubscriptionKey, referenceID, token)
+}
+
+type tokenCache struct {
+	mu        sync.Mutex
+	token     *AuthToken
+	expiresAt time.Time
+}
+
+func (t *tokenCache) get(fetch func() (*AuthToken, error)) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != nil && time.Now().Before(t.expiresAt.Add(-tokenExpiryMargin)) {
+		return t.token.AccessToken, nil
+	}
+
+	token, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("empty access token in auth response")
+	}
+
+	t.token = token
+	t.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return token.AccessToken, nil
+}
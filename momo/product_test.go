@@ -0,0 +1,202 @@
+package momo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProductClientsUseOwnCredentials(t *testing.T) {
+	tokenCalls := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/collection/token/", "/disbursement/token/":
+			tokenCalls[r.URL.Path]++
+			json.NewEncoder(w).Encode(AuthToken{
+				AccessToken: r.Header.Get("Ocp-Apim-Subscription-Key") + "-token",
+				TokenType:   "access_token",
+				ExpiresIn:   3600,
+			})
+		case "/disbursement/v1_0/account/balance":
+			if got := r.Header.Get("Authorization"); got != "Bearer disbursement-key-token" {
+				t.Errorf("unexpected authorization header %q", got)
+			}
+			json.NewEncoder(w).Encode(Balance{AvailableBalance: "10", Currency: "EUR"})
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{
+		Environment:  "sandbox",
+		BaseURL:      ts.URL,
+		Collection:   Credentials{SubscriptionKey: "collection-key", ApiUserID: "user-c", ApiKey: "key-c"},
+		Disbursement: Credentials{SubscriptionKey: "disbursement-key", ApiUserID: "user-d", ApiKey: "key-d"},
+	})
+
+	for i := 0; i < 2; i++ {
+		balance, err := client.Disbursement().GetAccountBalance()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance.AvailableBalance != "10" {
+			t.Fatalf("expected balance to be '10', got %s", balance.AvailableBalance)
+		}
+	}
+
+	token, err := client.Collection().Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "collection-key-token" {
+		t.Fatalf("expected collection token, got %s", token)
+	}
+
+	if tokenCalls["/disbursement/token/"] != 1 {
+		t.Fatalf("expected disbursement token to be cached, got %d token calls", tokenCalls["/disbursement/token/"])
+	}
+	if tokenCalls["/collection/token/"] != 1 {
+		t.Fatalf("expected one collection token call, got %d", tokenCalls["/collection/token/"])
+	}
+}
+
+func TestDisbursementTransfer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/disbursement/token/":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+		case "/disbursement/v1_0/transfer":
+			if r.Header.Get("X-Reference-Id") == "" {
+				t.Error("expected X-Reference-Id header")
+			}
+			if got := r.Header.Get("X-Callback-Url"); got != "https://callback.example.com/callbacks/transfer" {
+				t.Errorf("unexpected X-Callback-Url %q", got)
+			}
+			var transfer Transfer
+			json.NewDecoder(r.Body).Decode(&transfer)
+			if transfer.Payee.PartyId != "46733123454" {
+				t.Errorf("unexpected payee %+v", transfer.Payee)
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{
+		Environment: "sandbox",
+		BaseURL:     ts.URL,
+		Disbursement: Credentials{
+			SubscriptionKey: "disbursement-key",
+			ApiUserID:       "user-d",
+			ApiKey:          "key-d",
+			CallbackHost:    "callback.example.com",
+		},
+	})
+
+	referenceID, err := client.Disbursement().Transfer(Transfer{
+		Amount:      "50",
+		Currency:    "EUR",
+		ExternalId:  "654321",
+		Payee:       Party{PartyIdType: "MSISDN", PartyId: "46733123454"},
+		CallbackURL: "https://callback.example.com/callbacks/transfer",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if referenceID == "" {
+		t.Fatal("expected reference ID to be non-empty")
+	}
+}
+
+func TestValidateAccountHolder(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/collection/token/":
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+		case "/collection/v1_0/accountholder/msisdn/46733123453/active":
+			calls++
+			w.Write([]byte(`{"result": true}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{BaseURL: ts.URL})
+	active, err := client.Collection().ValidateAccountHolder(Party{PartyIdType: PartyIdTypeMSISDN, PartyId: "46733123453"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !active {
+		t.Fatal("expected the account holder to be active")
+	}
+
+	_, err = client.Collection().ValidateAccountHolder(Party{PartyIdType: "PHONE", PartyId: "46733123453"})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected one upstream call, got %d", calls)
+	}
+}
+
+func TestBalanceInCurrencyAndRestoredToken(t *testing.T) {
+	tokenCalls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/disbursement/token/":
+			tokenCalls++
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "fresh-token", ExpiresIn: 3600})
+		case "/disbursement/v1_0/account/balance/XOF":
+			if got := r.Header.Get("Authorization"); got != "Bearer saved-token" {
+				t.Errorf("expected the restored token, got %q", got)
+			}
+			json.NewEncoder(w).Encode(Balance{AvailableBalance: "6550", Currency: "XOF"})
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{BaseURL: ts.URL})
+	disbursement := client.Disbursement()
+	disbursement.RestoreToken(&AuthToken{AccessToken: "expired-token"}, time.Now().Add(-time.Minute))
+	if token, _ := disbursement.CachedToken(); token != nil {
+		t.Fatalf("expected an expired token to be ignored, got %+v", token)
+	}
+
+	expiry := time.Now().Add(time.Hour)
+	disbursement.RestoreToken(&AuthToken{AccessToken: "saved-token", TokenType: "access_token"}, expiry)
+	balance, err := disbursement.GetAccountBalanceInCurrency("XOF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.AvailableBalance != "6550" || balance.Currency != "XOF" {
+		t.Fatalf("unexpected balance %+v", balance)
+	}
+	if token, expiresAt := disbursement.CachedToken(); token.AccessToken != "saved-token" || !expiresAt.Equal(expiry) {
+		t.Fatalf("expected the restored token to stay cached, got %+v until %v", token, expiresAt)
+	}
+	if tokenCalls != 0 {
+		t.Fatalf("expected no token request, got %d", tokenCalls)
+	}
+
+	if _, err := disbursement.GetAccountBalanceInCurrency("euro"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+}
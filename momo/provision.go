@@ -0,0 +1,79 @@
+package momo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+const sandboxEnvironment = "sandbox"
+
+// Structure pour les identifiants obtenus par ProvisionSandbox
+type SandboxCredentials struct {
+	Product     Product `json:"product"`
+	Environment string  `json:"environment"`
+	Credentials
+}
+
+// Config renvoie une configuration client dont le produit provisionné utilise ces identifiants.
+func (s *SandboxCredentials) Config() Config {
+	cfg := Config{Environment: s.Environment}
+	switch s.Product {
+	case ProductDisbursement:
+		cfg.Disbursement = s.Credentials
+	case ProductRemittance:
+		cfg.Remittance = s.Credentials
+	default:
+		cfg.Collection = s.Credentials
+	}
+	return cfg
+}
+
+// ProvisionSandbox crée un utilisateur API et sa clé pour le produit collection,
+// puis vérifie qu'un token peut être obtenu avec ces identifiants.
+func ProvisionSandbox(ctx context.Context, subscriptionKey, callbackHost string) (*SandboxCredentials, error) {
+	return ProvisionSandboxProduct(ctx, ProductCollection, subscriptionKey, callbackHost)
+}
+
+// ProvisionSandboxProduct fait comme ProvisionSandbox pour un produit donné.
+func ProvisionSandboxProduct(ctx context.Context, product Product, subscriptionKey, callbackHost string) (*SandboxCredentials, error) {
+	if subscriptionKey == "" {
+		return nil, errors.New("subscription key is required")
+	}
+	if err := ValidateCallbackHost(callbackHost); err != nil {
+		return nil, err
+	}
+
+	c := NewClientFromConfig(Config{Environment: sandboxEnvironment})
+	referenceID := uuid.New().String()
+
+	log.Printf("Provisioning sandbox API user %s for %s", referenceID, product)
+	if err := c.createAPIUser(ctx, subscriptionKey, referenceID, callbackHost); err != nil {
+		return nil, fmt.Errorf("create API user: %w", err)
+	}
+
+	apiKey, err := c.createAPIKey(ctx, subscriptionKey, referenceID)
+	if err != nil {
+		return nil, fmt.Errorf("create API key: %w", err)
+	}
+
+	creds := Credentials{
+		SubscriptionKey: subscriptionKey,
+		ApiUserID:       referenceID,
+		ApiKey:          apiKey,
+		CallbackHost:    callbackHost,
+	}
+	if _, err := c.getAuthToken(ctx, product, creds); err != nil {
+		return nil, fmt.Errorf("verify credentials: %w", err)
+	}
+
+	log.Printf("Sandbox API user %s provisioned successfully", referenceID)
+	return &SandboxCredentials{
+		Product:     product,
+		Environment: sandboxEnvironment,
+		Credentials: creds,
+	}, nil
+}
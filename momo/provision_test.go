@@ -0,0 +1,56 @@
+package momo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProvisionSandbox(t *testing.T) {
+	var referenceID string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Ocp-Apim-Subscription-Key"); got != "test-subscription-key" {
+			t.Errorf("unexpected subscription key %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1_0/apiuser":
+			referenceID = r.Header.Get("X-Reference-Id")
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasSuffix(r.URL.Path, "/apikey"):
+			if r.URL.Path != "/v1_0/apiuser/"+referenceID+"/apikey" {
+				t.Errorf("unexpected API key request to %s", r.URL.Path)
+			}
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"apiKey": "test-api-key"})
+		case r.URL.Path == "/collection/token/":
+			user, key, _ := r.BasicAuth()
+			if user != referenceID || key != "test-api-key" {
+				t.Errorf("unexpected basic auth %s:%s", user, key)
+			}
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "test-access-token", ExpiresIn: 3600})
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	baseURL = ts.URL
+	creds, err := ProvisionSandbox(context.Background(), "test-subscription-key", "callback.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.ApiUserID == "" || creds.ApiUserID != referenceID {
+		t.Fatalf("expected API user ID to be %s, got %s", referenceID, creds.ApiUserID)
+	}
+	if creds.ApiKey != "test-api-key" {
+		t.Fatalf("expected API key to be 'test-api-key', got %s", creds.ApiKey)
+	}
+	if cfg := creds.Config(); cfg.Collection != creds.Credentials || cfg.Environment != "sandbox" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
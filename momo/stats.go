@@ -0,0 +1,157 @@
+package momo
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Nombre d'appels récents conservés pour les statistiques
+const recentCallsSize = 200
+
+// Structure pour un appel à l'API MoMo
+type UpstreamCall struct {
+	Operation  string        `json:"operation"`
+	StatusCode int           `json:"statusCode,omitempty"`
+	Code       string        `json:"code,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	Error      string        `json:"error,omitempty"`
+	At         time.Time     `json:"at"`
+}
+
+// Failed indique si l'appel a échoué : erreur réseau ou statut HTTP 4xx/5xx.
+func (u UpstreamCall) Failed() bool {
+	return u.Error != "" || u.StatusCode >= http.StatusBadRequest
+}
+
+// Structure pour les statistiques des appels récents d'une opération
+type OperationStats struct {
+	Operation  string        `json:"operation"`
+	Calls      int           `json:"calls"`
+	Errors     int           `json:"errors"`
+	AvgLatency time.Duration `json:"avgLatency"`
+	MaxLatency time.Duration `json:"maxLatency"`
+	LastError  string        `json:"lastError,omitempty"`
+	LastCallAt time.Time     `json:"lastCallAt"`
+}
+
+// Metrics reçoit les mesures du client : chaque appel à MoMo avec son statut HTTP et son
+// code d'erreur MoMo, chaque demande de token et chaque statut final résolu par un Tracker
+// ou reçu par un callback vérifié des handlers httpapi.
+// Le paquet metrics en fournit une implémentation Prometheus.
+type Metrics interface {
+	ObserveCall(operation string, statusCode int, code string, duration time.Duration)
+	ObserveTokenRefresh(product Product, err error)
+	ObserveFinalStatus(product Product, status, currency string)
+}
+
+// callLog conserve les derniers appels dans un tampon circulaire.
+type callLog struct {
+	mu    sync.Mutex
+	calls []UpstreamCall
+	next  int
+}
+
+func (l *callLog) add(call UpstreamCall) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.calls) < recentCallsSize {
+		l.calls = append(l.calls, call)
+		return
+	}
+	l.calls[l.next] = call
+	l.next = (l.next + 1) % recentCallsSize
+}
+
+func (l *callLog) recent() []UpstreamCall {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	calls := make([]UpstreamCall, 0, len(l.calls))
+	calls = append(calls, l.calls[l.next:]...)
+	return append(calls, l.calls[:l.next]...)
+}
+
+// do envoie une requête à l'API MoMo et enregistre sa durée et son résultat.
+func (c *Client) do(operation string, req *http.Request) (*http.Response, error) {
+	req, span := c.startCallSpan(operation, req)
+	defer span.End()
+
+	client := &http.Client{}
+	start := time.Now()
+	resp, err := client.Do(req)
+
+	call := UpstreamCall{Operation: operation, Duration: time.Since(start), At: start}
+	if err != nil {
+		call.Error = err.Error()
+	} else {
+		call.StatusCode = resp.StatusCode
+		if resp.StatusCode >= http.StatusBadRequest {
+			// Le code d'erreur MoMo est lu dans le corps, remis en place pour l'appelant
+			body, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			if readErr == nil {
+				call.Code = newAPIError(operation, resp.StatusCode, body).Code
+			}
+		}
+	}
+	c.calls.add(call)
+	if call.StatusCode != 0 {
+		span.SetAttributes(attribute.Int(AttrStatusCode, call.StatusCode))
+	}
+	if call.Code != "" {
+		span.SetAttributes(attribute.String(AttrErrorCode, call.Code))
+	}
+	if call.Failed() {
+		span.SetStatus(codes.Error, call.Error)
+	}
+	if c.Metrics != nil {
+		c.Metrics.ObserveCall(operation, call.StatusCode, call.Code, call.Duration)
+	}
+	return resp, err
+}
+
+// RecentCalls renvoie les derniers appels à l'API MoMo, du plus ancien au plus récent.
+func (c *Client) RecentCalls() []UpstreamCall {
+	return c.calls.recent()
+}
+
+// UpstreamStats résume les derniers appels à l'API MoMo par opération.
+func (c *Client) UpstreamStats() []OperationStats {
+	byOperation := make(map[string]*OperationStats)
+	total := make(map[string]time.Duration)
+	for _, call := range c.RecentCalls() {
+		stats, ok := byOperation[call.Operation]
+		if !ok {
+			stats = &OperationStats{Operation: call.Operation}
+			byOperation[call.Operation] = stats
+		}
+		stats.Calls++
+		total[call.Operation] += call.Duration
+		if call.Duration > stats.MaxLatency {
+			stats.MaxLatency = call.Duration
+		}
+		if call.Failed() {
+			stats.Errors++
+			stats.LastError = call.Error
+			if stats.LastError == "" {
+				stats.LastError = http.StatusText(call.StatusCode)
+			}
+		}
+		stats.LastCallAt = call.At
+	}
+
+	result := make([]OperationStats, 0, len(byOperation))
+	for name, stats := range byOperation {
+		stats.AvgLatency = total[name] / time.Duration(stats.Calls)
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Operation < result[j].Operation })
+	return result
+}
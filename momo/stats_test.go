@@ -0,0 +1,91 @@
+package momo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUpstreamStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/collection/token/" {
+			w.Write([]byte(`{"access_token": "token", "token_type": "access_token", "expires_in": 3600}`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	client := NewClientFromConfig(Config{BaseURL: ts.URL})
+	if _, err := client.Collection().GetAccountBalance(); err == nil {
+		t.Fatal("expected an error from the balance request")
+	}
+
+	stats := client.UpstreamStats()
+	if len(stats) != 2 {
+		t.Fatalf("expected stats for 2 operations, got %+v", stats)
+	}
+	if stats[0].Operation != "get account balance" || stats[0].Calls != 1 || stats[0].Errors != 1 {
+		t.Fatalf("unexpected balance stats %+v", stats[0])
+	}
+	if stats[1].Operation != "get auth token" || stats[1].Errors != 0 {
+		t.Fatalf("unexpected token stats %+v", stats[1])
+	}
+}
+
+func TestCallLogKeepsRecentCalls(t *testing.T) {
+	var log callLog
+	for i := 0; i < recentCallsSize+5; i++ {
+		log.add(UpstreamCall{StatusCode: i})
+	}
+	calls := log.recent()
+	if len(calls) != recentCallsSize || calls[0].StatusCode != 5 || calls[len(calls)-1].StatusCode != recentCallsSize+4 {
+		t.Fatalf("unexpected recent calls: %d, first %d", len(calls), calls[0].StatusCode)
+	}
+}
+
+type recordedMetrics struct {
+	calls  []string
+	tokens int
+}
+
+func (m *recordedMetrics) ObserveCall(operation string, statusCode int, code string, duration time.Duration) {
+	m.calls = append(m.calls, fmt.Sprintf("%s %d %s", operation, statusCode, code))
+}
+
+func (m *recordedMetrics) ObserveTokenRefresh(product Product, err error) {
+	m.tokens++
+}
+
+func (m *recordedMetrics) ObserveFinalStatus(product Product, status, currency string) {}
+
+func TestMetricsReceiveErrorCodes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/collection/token/" {
+			w.Write([]byte(`{"access_token": "token", "token_type": "access_token", "expires_in": 3600}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code": "RESOURCE_NOT_FOUND", "message": "Requested resource was not found."}`))
+	}))
+	defer ts.Close()
+
+	metrics := &recordedMetrics{}
+	client := NewClientFromConfig(Config{BaseURL: ts.URL})
+	client.Metrics = metrics
+
+	_, err := client.Collection().GetPaymentStatus("ref-1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != CodeResourceNotFound {
+		t.Fatalf("expected the error body to reach the caller, got %v", err)
+	}
+	if metrics.tokens != 1 {
+		t.Fatalf("expected 1 token refresh, got %d", metrics.tokens)
+	}
+	if len(metrics.calls) != 2 || metrics.calls[1] != "get payment status 404 RESOURCE_NOT_FOUND" {
+		t.Fatalf("unexpected calls %v", metrics.calls)
+	}
+}
@@ -0,0 +1,122 @@
+package momo
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Nom de l'instrumentation OpenTelemetry du client
+const TracerName = "github.com/enzoforreal/mtn-momo-api/momo"
+
+// Attributs des spans du client
+const (
+	AttrOperation     = "momo.operation"
+	AttrProduct       = "momo.product"
+	AttrReferenceID   = "momo.reference_id"
+	AttrPaymentStatus = "momo.status"
+	AttrErrorCode     = "momo.error_code"
+	AttrCallbackType  = "momo.callback_type"
+	AttrStatusCode    = "http.response.status_code"
+)
+
+// tracer renvoie le traceur du client. Sans TracerProvider, le fournisseur global est
+// utilisé ; il n'enregistre rien tant que l'application n'en a pas configuré un.
+func (c *Client) tracer() trace.Tracer {
+	provider := c.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(TracerName)
+}
+
+// startSpan démarre le span d'une méthode du client.
+func (p *ProductClient) startSpan(ctx context.Context, method, referenceID string) (context.Context, trace.Span) {
+	ctx, span := p.client.tracer().Start(ctx, "momo."+method, trace.WithAttributes(
+		attribute.String(AttrOperation, method),
+		attribute.String(AttrProduct, string(p.Product)),
+	))
+	if referenceID != "" {
+		span.SetAttributes(attribute.String(AttrReferenceID, referenceID))
+	}
+	return ctx, span
+}
+
+// endSpan ajoute les attributs non vides, enregistre l'erreur éventuelle et termine le span.
+func endSpan(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	for _, attr := range attrs {
+		if attr.Key != "" {
+			span.SetAttributes(attr)
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func referenceAttribute(referenceID string) attribute.KeyValue {
+	if referenceID == "" {
+		return attribute.KeyValue{}
+	}
+	return attribute.String(AttrReferenceID, referenceID)
+}
+
+func paymentStatusAttribute(result *RequestToPayResult) attribute.KeyValue {
+	if result == nil {
+		return attribute.KeyValue{}
+	}
+	return attribute.String(AttrPaymentStatus, result.Status)
+}
+
+func transferStatusAttribute(result *TransferResult) attribute.KeyValue {
+	if result == nil {
+		return attribute.KeyValue{}
+	}
+	return attribute.String(AttrPaymentStatus, result.Status)
+}
+
+// startCallSpan démarre le span d'un appel HTTP à MoMo. Le contexte de trace n'est
+// propagé dans les en-têtes de la requête que si PropagateTrace est activé.
+func (c *Client) startCallSpan(operation string, req *http.Request) (*http.Request, trace.Span) {
+	ctx, span := c.tracer().Start(req.Context(), "momo "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String(AttrOperation, operation),
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+		),
+	)
+	if referenceID := req.Header.Get("X-Reference-Id"); referenceID != "" {
+		span.SetAttributes(attribute.String(AttrReferenceID, referenceID))
+	}
+	if c.PropagateTrace {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	}
+	return req.WithContext(ctx), span
+}
+
+// traceParent renvoie l'en-tête W3C traceparent du span courant, pour relier plus tard
+// le callback d'une opération à la requête qui l'a créée.
+func traceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// LinkPending relie le span courant à celui de la requête qui a créé l'opération en attente.
+func LinkPending(ctx context.Context, pending PendingRequest) {
+	if pending.TraceParent == "" {
+		return
+	}
+	origin := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{"traceparent": pending.TraceParent})
+	if sc := trace.SpanContextFromContext(origin); sc.IsValid() {
+		trace.SpanFromContext(ctx).AddLink(trace.Link{SpanContext: sc})
+	}
+}
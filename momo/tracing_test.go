@@ -0,0 +1,145 @@
+package momo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracedClient(t *testing.T) (*Client, *tracetest.InMemoryExporter, func()) {
+	status := StatusPending
+	client, closeServer := newTrackerTestClient(t, &status, &sync.Mutex{})
+	exporter := tracetest.NewInMemoryExporter()
+	client.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return client, exporter, closeServer
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q not found", name)
+	return tracetest.SpanStub{}
+}
+
+func spanAttribute(span tracetest.SpanStub, key string) attribute.Value {
+	for _, attr := range span.Attributes {
+		if string(attr.Key) == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestClientMethodSpans(t *testing.T) {
+	client, exporter, closeServer := newTracedClient(t)
+	defer closeServer()
+
+	referenceID, err := client.Collection().RequestToPay(RequestToPay{Amount: "100", Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	method := findSpan(t, spans, "momo.RequestToPay")
+	token := findSpan(t, spans, "momo.GetAuthToken")
+	tokenCall := findSpan(t, spans, "momo get auth token")
+	call := findSpan(t, spans, "momo request payment")
+
+	if token.Parent.SpanID() != method.SpanContext.SpanID() || call.Parent.SpanID() != method.SpanContext.SpanID() {
+		t.Fatal("expected the token and MoMo call spans to be children of the method span")
+	}
+	if tokenCall.Parent.SpanID() != token.SpanContext.SpanID() {
+		t.Fatal("expected the token request to be a child of the token span")
+	}
+	if spanAttribute(method, AttrReferenceID).AsString() != referenceID {
+		t.Fatalf("expected the reference ID on the method span, got %v", method.Attributes)
+	}
+	if spanAttribute(call, AttrStatusCode).AsInt64() != http.StatusAccepted {
+		t.Fatalf("expected the HTTP status on the call span, got %v", call.Attributes)
+	}
+}
+
+func TestCallbackSpanLinksToRequest(t *testing.T) {
+	client, exporter, closeServer := newTracedClient(t)
+	defer closeServer()
+
+	tracker := NewTracker(client, func(ctx context.Context, event TrackerEvent) {})
+	defer tracker.Stop()
+
+	referenceID, err := client.Collection().RequestToPay(RequestToPay{Amount: "100", Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := findSpan(t, exporter.GetSpans(), "momo.RequestToPay")
+
+	handler := NewCallbackHandler(tracker.HandleCallback)
+	handler.TracerProvider = client.TracerProvider
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/callbacks/requesttopay/"+referenceID, strings.NewReader(`{"status": "SUCCESSFUL"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	callback := findSpan(t, exporter.GetSpans(), "momo.callback")
+	if spanAttribute(callback, AttrReferenceID).AsString() != referenceID {
+		t.Fatalf("expected the reference ID on the callback span, got %v", callback.Attributes)
+	}
+	if len(callback.Links) != 1 || callback.Links[0].SpanContext.TraceID() != request.SpanContext.TraceID() {
+		t.Fatalf("expected the callback span to link to the payment request, got %v", callback.Links)
+	}
+}
+
+func TestTraceContextIsNotSentToMoMoByDefault(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	var mu sync.Mutex
+	var traceparents []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/collection/token/" {
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+			return
+		}
+		mu.Lock()
+		traceparents = append(traceparents, r.Header.Get("Traceparent"))
+		mu.Unlock()
+		w.Write([]byte(`{"availableBalance": "1000", "currency": "EUR"}`))
+	}))
+	defer upstream.Close()
+
+	client := NewClientFromConfig(Config{
+		Environment: "sandbox",
+		BaseURL:     upstream.URL,
+		Collection:  Credentials{SubscriptionKey: "test-subscription-key", ApiUserID: "test-api-user-id", ApiKey: "test-api-key"},
+	})
+	client.TracerProvider = sdktrace.NewTracerProvider()
+
+	if _, err := client.Collection().GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	client.PropagateTrace = true
+	if _, err := client.Collection().GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(traceparents) != 2 || traceparents[0] != "" || traceparents[1] == "" {
+		t.Fatalf("expected traceparent only with PropagateTrace, got %q", traceparents)
+	}
+}
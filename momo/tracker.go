@@ -0,0 +1,199 @@
+package momo
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Origine de la résolution d'une opération suivie
+const (
+	ResolvedByCallback = "callback"
+	ResolvedByPoll     = "poll"
+	ResolvedByExpiry   = "expiry"
+)
+
+// Valeurs par défaut du suivi
+const (
+	defaultCallbackWindow = time.Minute
+	defaultPollInterval   = 30 * time.Second
+	defaultTrackMaxAge    = 24 * time.Hour
+)
+
+// Structure pour l'événement final émis une seule fois par opération suivie
+type TrackerEvent struct {
+	Pending    PendingRequest `json:"pending"`
+	Status     string         `json:"status"`
+	Source     string         `json:"source"`
+	Result     interface{}    `json:"result,omitempty"`
+	ResolvedAt time.Time      `json:"resolvedAt"`
+}
+
+// Tracker suit chaque opération acceptée jusqu'à son statut final. Il attend le callback
+// pendant CallbackWindow, puis interroge MoMo toutes les PollInterval. Une opération non
+// résolue après MaxAge est émise avec la source ResolvedByExpiry et son dernier statut connu.
+//
+// Tracker implémente PendingStore : affecté à client.Pending, il suit automatiquement
+// chaque opération acceptée par le client. HandleCallback s'utilise comme CallbackFunc,
+// directement ou derrière un CallbackVerifier.
+type Tracker struct {
+	CallbackWindow time.Duration
+	PollInterval   time.Duration
+	MaxAge         time.Duration
+
+	client  *Client
+	store   PendingStore
+	onFinal func(ctx context.Context, event TrackerEvent)
+
+	mu      sync.Mutex
+	tracked map[string]*time.Timer
+	stopped bool
+}
+
+var _ PendingStore = (*Tracker)(nil)
+
+// NewTracker crée un suivi qui appelle onFinal une fois par opération. Les opérations en
+// attente sont conservées dans le PendingStore actuel du client, ou en mémoire à défaut.
+// Le tracker remplace ensuite client.Pending.
+func NewTracker(client *Client, onFinal func(ctx context.Context, event TrackerEvent)) *Tracker {
+	store := client.Pending
+	if store == nil {
+		store = NewMemoryPendingStore()
+	}
+	t := &Tracker{
+		CallbackWindow: defaultCallbackWindow,
+		PollInterval:   defaultPollInterval,
+		MaxAge:         defaultTrackMaxAge,
+		client:         client,
+		store:          store,
+		onFinal:        onFinal,
+		tracked:        make(map[string]*time.Timer),
+	}
+	client.Pending = t
+	return t
+}
+
+// Add enregistre l'opération et démarre son suivi.
+func (t *Tracker) Add(ctx context.Context, pending PendingRequest) error {
+	if err := t.store.Add(ctx, pending); err != nil {
+		return err
+	}
+	t.Track(pending)
+	return nil
+}
+
+func (t *Tracker) Get(ctx context.Context, referenceID string) (PendingRequest, bool, error) {
+	return t.store.Get(ctx, referenceID)
+}
+
+func (t *Tracker) Remove(ctx context.Context, referenceID string) error {
+	return t.store.Remove(ctx, referenceID)
+}
+
+// Track suit une opération déjà présente dans le store, par exemple après un redémarrage.
+func (t *Tracker) Track(pending PendingRequest) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return
+	}
+	if _, ok := t.tracked[pending.ReferenceID]; ok {
+		return
+	}
+	t.tracked[pending.ReferenceID] = time.AfterFunc(t.CallbackWindow, func() {
+		t.poll(pending)
+	})
+}
+
+// Tracking indique si une opération est encore suivie.
+func (t *Tracker) Tracking(referenceID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.tracked[referenceID]
+	return ok
+}
+
+// HandleCallback résout l'opération correspondante si le callback porte un statut final.
+func (t *Tracker) HandleCallback(ctx context.Context, event CallbackEvent) error {
+	if !IsFinalStatus(event.Status()) {
+		return nil
+	}
+	ref := event.Meta().ReferenceID
+	pending, ok, err := t.store.Get(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	LinkPending(ctx, pending)
+	t.resolve(ctx, pending, event.Status(), ResolvedByCallback, CallbackResult(event))
+	return nil
+}
+
+// Stop arrête toutes les interrogations programmées. Les opérations restent dans le store.
+func (t *Tracker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopped = true
+	for ref, timer := range t.tracked {
+		timer.Stop()
+		delete(t.tracked, ref)
+	}
+}
+
+func (t *Tracker) poll(pending PendingRequest) {
+	ctx := context.Background()
+	status, result, err := t.client.pendingResult(ctx, pending)
+	if err != nil {
+		log.Printf("Error polling status for reference ID %s: %v", pending.ReferenceID, err)
+	}
+
+	if err == nil && IsFinalStatus(status) {
+		t.resolve(ctx, pending, status, ResolvedByPoll, result)
+		return
+	}
+	if time.Since(pending.CreatedAt) >= t.MaxAge {
+		t.resolve(ctx, pending, status, ResolvedByExpiry, result)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if timer, ok := t.tracked[pending.ReferenceID]; ok {
+		timer.Reset(t.PollInterval)
+	}
+}
+
+// resolve émet l'événement final, une seule fois par référence.
+func (t *Tracker) resolve(ctx context.Context, pending PendingRequest, status, source string, result interface{}) {
+	t.mu.Lock()
+	timer, ok := t.tracked[pending.ReferenceID]
+	if ok {
+		timer.Stop()
+		delete(t.tracked, pending.ReferenceID)
+	}
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := t.store.Remove(ctx, pending.ReferenceID); err != nil {
+		log.Printf("Error removing pending request %s: %v", pending.ReferenceID, err)
+	}
+
+	log.Printf("Reference ID %s resolved by %s with status %s", pending.ReferenceID, source, status)
+	if t.client.Metrics != nil {
+		t.client.Metrics.ObserveFinalStatus(pending.Product, status, pending.Currency)
+	}
+	t.onFinal(ctx, TrackerEvent{
+		Pending:    pending,
+		Status:     status,
+		Source:     source,
+		Result:     result,
+		ResolvedAt: time.Now(),
+	})
+}
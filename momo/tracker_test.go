@@ -0,0 +1,119 @@
+package momo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTrackerTestClient(t *testing.T, status *string, mu *sync.Mutex) (*Client, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/collection/token/":
+			json.NewEncoder(w).Encode(AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+		case r.URL.Path == "/collection/v1_0/requesttopay":
+			w.WriteHeader(http.StatusAccepted)
+		case strings.HasPrefix(r.URL.Path, "/collection/v2_0/payment/"):
+			mu.Lock()
+			defer mu.Unlock()
+			json.NewEncoder(w).Encode(RequestToPayResult{Status: *status})
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	client := NewClientFromConfig(Config{
+		Environment: "sandbox",
+		BaseURL:     ts.URL,
+		Collection:  Credentials{SubscriptionKey: "collection-key", ApiUserID: "user-c", ApiKey: "key-c"},
+	})
+	return client, ts.Close
+}
+
+func TestTrackerFallsBackToPolling(t *testing.T) {
+	var mu sync.Mutex
+	status := StatusPending
+	client, closeServer := newTrackerTestClient(t, &status, &mu)
+	defer closeServer()
+
+	events := make(chan TrackerEvent, 2)
+	tracker := NewTracker(client, func(ctx context.Context, event TrackerEvent) {
+		events <- event
+	})
+	tracker.CallbackWindow = 10 * time.Millisecond
+	tracker.PollInterval = 10 * time.Millisecond
+	defer tracker.Stop()
+
+	referenceID, err := client.Collection().RequestToPay(RequestToPay{Amount: "100", Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tracker.Tracking(referenceID) {
+		t.Fatal("expected the payment to be tracked")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	status = StatusSuccessful
+	mu.Unlock()
+
+	select {
+	case event := <-events:
+		if event.Pending.ReferenceID != referenceID || event.Status != StatusSuccessful || event.Source != ResolvedByPoll {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("payment was not resolved by polling")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if len(events) != 0 {
+		t.Fatal("expected exactly one final event")
+	}
+}
+
+func TestTrackerResolvesOnCallback(t *testing.T) {
+	var mu sync.Mutex
+	status := StatusSuccessful
+	client, closeServer := newTrackerTestClient(t, &status, &mu)
+	defer closeServer()
+
+	var events []TrackerEvent
+	tracker := NewTracker(client, func(ctx context.Context, event TrackerEvent) {
+		events = append(events, event)
+	})
+	tracker.CallbackWindow = time.Hour
+	defer tracker.Stop()
+
+	referenceID, err := client.Collection().RequestToPay(RequestToPay{Amount: "100", Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callback := RequestToPayEvent{
+		CallbackMeta: CallbackMeta{Type: CallbackRequestToPay, ReferenceID: referenceID},
+		Result:       RequestToPayResult{Status: StatusSuccessful},
+	}
+	for i := 0; i < 2; i++ {
+		if err := tracker.HandleCallback(context.Background(), callback); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected exactly one final event, got %d", len(events))
+	}
+	if events[0].Source != ResolvedByCallback {
+		t.Fatalf("expected the payment to be resolved by callback, got %s", events[0].Source)
+	}
+	if tracker.Tracking(referenceID) {
+		t.Fatal("expected the payment not to be tracked anymore")
+	}
+}
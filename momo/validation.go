@@ -0,0 +1,176 @@
+package momo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// ErrValidation est enveloppée par ValidationError
+var ErrValidation = errors.New("validation failed")
+
+// ErrInvalidReferenceID signale un identifiant de référence qui n'est pas un UUID
+var ErrInvalidReferenceID = errors.New("reference ID must be a UUID")
+
+// Types d'identifiant acceptés par MoMo pour un payeur ou un bénéficiaire
+const (
+	PartyIdTypeMSISDN    = "MSISDN"
+	PartyIdTypeEmail     = "EMAIL"
+	PartyIdTypePartyCode = "PARTY_CODE"
+)
+
+// Limites appliquées aux demandes avant leur envoi à MoMo
+const (
+	MaxAmount        = 1_000_000_000
+	MaxMessageLength = 160
+	MaxExternalIDLen = 128
+)
+
+var (
+	amountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]{1,2})?$`)
+	// MSISDN au format E.164, sans le préfixe +
+	msisdnPattern = regexp.MustCompile(`^[1-9][0-9]{7,14}$`)
+	emailPattern  = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// Structure pour une erreur de validation portant sur un champ
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError regroupe toutes les erreurs de champ d'une demande.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + ": " + field.Message
+	}
+	return fmt.Sprintf("%v: %s", ErrValidation, strings.Join(parts, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *ValidationError) orNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ValidateReferenceID vérifie qu'un identifiant de référence est un UUID sous sa forme
+// canonique, la seule générée par le client et acceptée par MoMo.
+func ValidateReferenceID(referenceID string) error {
+	if len(referenceID) != 36 {
+		return ErrInvalidReferenceID
+	}
+	if _, err := uuid.Parse(referenceID); err != nil {
+		return ErrInvalidReferenceID
+	}
+	return nil
+}
+
+// Validate vérifie une demande de paiement sans appeler MoMo. L'erreur renvoyée est
+// un *ValidationError listant tous les champs invalides.
+func (r RequestToPay) Validate() error {
+	v := &ValidationError{}
+	validateOperation(v, r.Amount, r.Currency, r.ExternalId, r.PayerMessage, r.PayeeNote)
+	validateParty(v, "payer", r.Payer)
+	return v.orNil()
+}
+
+// Validate vérifie un transfert sans appeler MoMo.
+func (t Transfer) Validate() error {
+	v := &ValidationError{}
+	validateOperation(v, t.Amount, t.Currency, t.ExternalId, t.PayerMessage, t.PayeeNote)
+	validateParty(v, "payee", t.Payee)
+	return v.orNil()
+}
+
+func validateOperation(v *ValidationError, amount, currency, externalID, payerMessage, payeeNote string) {
+	switch value, err := strconv.ParseFloat(amount, 64); {
+	case amount == "":
+		v.add("amount", "is required")
+	case !amountPattern.MatchString(amount) || err != nil:
+		v.add("amount", "must be a positive decimal number with at most 2 decimals")
+	case value <= 0 || value > MaxAmount:
+		v.add("amount", "must be greater than 0 and at most %d", MaxAmount)
+	}
+
+	if currency == "" {
+		v.add("currency", "is required")
+	} else if !IsCurrencyCode(currency) {
+		v.add("currency", "must be an ISO 4217 currency code")
+	}
+
+	if strings.TrimSpace(externalID) == "" {
+		v.add("externalId", "is required")
+	} else if utf8.RuneCountInString(externalID) > MaxExternalIDLen {
+		v.add("externalId", "must be at most %d characters", MaxExternalIDLen)
+	}
+
+	if utf8.RuneCountInString(payerMessage) > MaxMessageLength {
+		v.add("payerMessage", "must be at most %d characters", MaxMessageLength)
+	}
+	if utf8.RuneCountInString(payeeNote) > MaxMessageLength {
+		v.add("payeeNote", "must be at most %d characters", MaxMessageLength)
+	}
+}
+
+func validateParty(v *ValidationError, field string, party Party) {
+	switch party.PartyIdType {
+	case PartyIdTypeMSISDN:
+		if !msisdnPattern.MatchString(party.PartyId) {
+			v.add(field+".partyId", "must be an MSISDN of 8 to 15 digits, with country code and without +")
+		}
+	case PartyIdTypeEmail:
+		if !emailPattern.MatchString(party.PartyId) {
+			v.add(field+".partyId", "must be an email address")
+		}
+	case PartyIdTypePartyCode:
+		if strings.TrimSpace(party.PartyId) == "" {
+			v.add(field+".partyId", "is required")
+		}
+	case "":
+		v.add(field+".partyIdType", "is required")
+	default:
+		v.add(field+".partyIdType", "must be one of %s, %s or %s", PartyIdTypeMSISDN, PartyIdTypeEmail, PartyIdTypePartyCode)
+	}
+}
+
+// IsCurrencyCode indique si code est un code de devise ISO 4217 actif.
+func IsCurrencyCode(code string) bool {
+	_, ok := currencyCodes[code]
+	return ok
+}
+
+// Codes de devise ISO 4217 actifs
+var currencyCodes = func() map[string]struct{} {
+	const codes = "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BRL " +
+		"BSD BTN BWP BYN BZD CAD CDF CHF CLP CNY COP CRC CUP CVE CZK DJF DKK DOP DZD EGP " +
+		"ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR " +
+		"IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL " +
+		"LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN NAD NGN NIO NOK NPR " +
+		"NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD " +
+		"SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS UAH UGX " +
+		"USD UYU UZS VES VND VUV WST XAF XCD XOF XPF YER ZAR ZMW ZWL"
+	set := make(map[string]struct{})
+	for _, code := range strings.Fields(codes) {
+		set[code] = struct{}{}
+	}
+	return set
+}()
@@ -0,0 +1,77 @@
+package momo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validRequestToPay() RequestToPay {
+	return RequestToPay{
+		Amount:     "100.50",
+		Currency:   "EUR",
+		ExternalId: "123456",
+		Payer:      Payer{PartyIdType: PartyIdTypeMSISDN, PartyId: "46733123453"},
+	}
+}
+
+func TestRequestToPayValidate(t *testing.T) {
+	if err := validRequestToPay().Validate(); err != nil {
+		t.Fatalf("expected a valid request, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*RequestToPay)
+		field  string
+	}{
+		{"missing amount", func(r *RequestToPay) { r.Amount = "" }, "amount"},
+		{"non-numeric amount", func(r *RequestToPay) { r.Amount = "ten" }, "amount"},
+		{"too many decimals", func(r *RequestToPay) { r.Amount = "1.005" }, "amount"},
+		{"zero amount", func(r *RequestToPay) { r.Amount = "0" }, "amount"},
+		{"amount too large", func(r *RequestToPay) { r.Amount = "1000000001" }, "amount"},
+		{"unknown currency", func(r *RequestToPay) { r.Currency = "XYZ" }, "currency"},
+		{"lowercase currency", func(r *RequestToPay) { r.Currency = "eur" }, "currency"},
+		{"missing externalId", func(r *RequestToPay) { r.ExternalId = " " }, "externalId"},
+		{"unknown partyIdType", func(r *RequestToPay) { r.Payer.PartyIdType = "PHONE" }, "payer.partyIdType"},
+		{"empty MSISDN", func(r *RequestToPay) { r.Payer.PartyId = "" }, "payer.partyId"},
+		{"MSISDN with plus", func(r *RequestToPay) { r.Payer.PartyId = "+46733123453" }, "payer.partyId"},
+		{"invalid email", func(r *RequestToPay) { r.Payer = Payer{PartyIdType: PartyIdTypeEmail, PartyId: "nobody"} }, "payer.partyId"},
+		{"payer message too long", func(r *RequestToPay) { r.PayerMessage = strings.Repeat("é", MaxMessageLength+1) }, "payerMessage"},
+		{"payee note too long", func(r *RequestToPay) { r.PayeeNote = strings.Repeat("a", MaxMessageLength+1) }, "payeeNote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequestToPay()
+			tt.modify(&req)
+
+			err := req.Validate()
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) || !errors.Is(err, ErrValidation) {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+			if len(invalid.Fields) != 1 || invalid.Fields[0].Field != tt.field {
+				t.Fatalf("expected a single error on %s, got %+v", tt.field, invalid.Fields)
+			}
+		})
+	}
+}
+
+func TestTransferValidateReportsAllFields(t *testing.T) {
+	err := Transfer{Amount: "abc", Payee: Party{PartyIdType: PartyIdTypeMSISDN}}.Validate()
+
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, field := range invalid.Fields {
+		fields[field.Field] = true
+	}
+	for _, field := range []string{"amount", "currency", "externalId", "payee.partyId"} {
+		if !fields[field] {
+			t.Errorf("expected an error on %s, got %+v", field, invalid.Fields)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package momopb contient la définition protobuf du service gRPC de la passerelle et le
+// code Go généré, messages et stubs client et serveur.
+package momopb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative momo.proto
@@ -0,0 +1,293 @@
+// Service gRPC de la passerelle MTN MoMo. Il expose les mêmes opérations que l'API REST,
+// avec la même authentification : une clé d'appelant dans la métadonnée x-api-key ou un
+// JWT dans la métadonnée authorization ("Bearer <token>").
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: momo.proto
+
+package momopb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MomoService_RequestToPay_FullMethodName          = "/momo.v1.MomoService/RequestToPay"
+	MomoService_GetPaymentStatus_FullMethodName      = "/momo.v1.MomoService/GetPaymentStatus"
+	MomoService_GetAccountBalance_FullMethodName     = "/momo.v1.MomoService/GetAccountBalance"
+	MomoService_ValidateAccountHolder_FullMethodName = "/momo.v1.MomoService/ValidateAccountHolder"
+	MomoService_WatchPayment_FullMethodName          = "/momo.v1.MomoService/WatchPayment"
+)
+
+// MomoServiceClient is the client API for MomoService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MomoServiceClient interface {
+	// RequestToPay envoie une demande de paiement au payeur. Portée payments:create.
+	RequestToPay(ctx context.Context, in *RequestToPayRequest, opts ...grpc.CallOption) (*RequestToPayResponse, error)
+	// GetPaymentStatus renvoie le statut d'une demande de paiement. Portée payments:read.
+	GetPaymentStatus(ctx context.Context, in *GetPaymentStatusRequest, opts ...grpc.CallOption) (*Payment, error)
+	// GetAccountBalance renvoie le solde du compte de collecte. Portée balance:read.
+	GetAccountBalance(ctx context.Context, in *GetAccountBalanceRequest, opts ...grpc.CallOption) (*Balance, error)
+	// ValidateAccountHolder indique si le titulaire d'un compte est actif. Portée payments:create.
+	ValidateAccountHolder(ctx context.Context, in *ValidateAccountHolderRequest, opts ...grpc.CallOption) (*ValidateAccountHolderResponse, error)
+	// WatchPayment diffuse le statut actuel d'un paiement puis chacun de ses changements,
+	// jusqu'au statut final. Portée payments:read.
+	WatchPayment(ctx context.Context, in *WatchPaymentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PaymentUpdate], error)
+}
+
+type momoServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMomoServiceClient(cc grpc.ClientConnInterface) MomoServiceClient {
+	return &momoServiceClient{cc}
+}
+
+func (c *momoServiceClient) RequestToPay(ctx context.Context, in *RequestToPayRequest, opts ...grpc.CallOption) (*RequestToPayResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RequestToPayResponse)
+	err := c.cc.Invoke(ctx, MomoService_RequestToPay_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *momoServiceClient) GetPaymentStatus(ctx context.Context, in *GetPaymentStatusRequest, opts ...grpc.CallOption) (*Payment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Payment)
+	err := c.cc.Invoke(ctx, MomoService_GetPaymentStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *momoServiceClient) GetAccountBalance(ctx context.Context, in *GetAccountBalanceRequest, opts ...grpc.CallOption) (*Balance, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Balance)
+	err := c.cc.Invoke(ctx, MomoService_GetAccountBalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *momoServiceClient) ValidateAccountHolder(ctx context.Context, in *ValidateAccountHolderRequest, opts ...grpc.CallOption) (*ValidateAccountHolderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateAccountHolderResponse)
+	err := c.cc.Invoke(ctx, MomoService_ValidateAccountHolder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *momoServiceClient) WatchPayment(ctx context.Context, in *WatchPaymentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PaymentUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MomoService_ServiceDesc.Streams[0], MomoService_WatchPayment_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchPaymentRequest, PaymentUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MomoService_WatchPaymentClient = grpc.ServerStreamingClient[PaymentUpdate]
+
+// MomoServiceServer is the server API for MomoService service.
+// All implementations must embed UnimplementedMomoServiceServer
+// for forward compatibility.
+type MomoServiceServer interface {
+	// RequestToPay envoie une demande de paiement au payeur. Portée payments:create.
+	RequestToPay(context.Context, *RequestToPayRequest) (*RequestToPayResponse, error)
+	// GetPaymentStatus renvoie le statut d'une demande de paiement. Portée payments:read.
+	GetPaymentStatus(context.Context, *GetPaymentStatusRequest) (*Payment, error)
+	// GetAccountBalance renvoie le solde du compte de collecte. Portée balance:read.
+	GetAccountBalance(context.Context, *GetAccountBalanceRequest) (*Balance, error)
+	// ValidateAccountHolder indique si le titulaire d'un compte est actif. Portée payments:create.
+	ValidateAccountHolder(context.Context, *ValidateAccountHolderRequest) (*ValidateAccountHolderResponse, error)
+	// WatchPayment diffuse le statut actuel d'un paiement puis chacun de ses changements,
+	// jusqu'au statut final. Portée payments:read.
+	WatchPayment(*WatchPaymentRequest, grpc.ServerStreamingServer[PaymentUpdate]) error
+	mustEmbedUnimplementedMomoServiceServer()
+}
+
+// UnimplementedMomoServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMomoServiceServer struct{}
+
+func (UnimplementedMomoServiceServer) RequestToPay(context.Context, *RequestToPayRequest) (*RequestToPayResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestToPay not implemented")
+}
+func (UnimplementedMomoServiceServer) GetPaymentStatus(context.Context, *GetPaymentStatusRequest) (*Payment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentStatus not implemented")
+}
+func (UnimplementedMomoServiceServer) GetAccountBalance(context.Context, *GetAccountBalanceRequest) (*Balance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountBalance not implemented")
+}
+func (UnimplementedMomoServiceServer) ValidateAccountHolder(context.Context, *ValidateAccountHolderRequest) (*ValidateAccountHolderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateAccountHolder not implemented")
+}
+func (UnimplementedMomoServiceServer) WatchPayment(*WatchPaymentRequest, grpc.ServerStreamingServer[PaymentUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPayment not implemented")
+}
+func (UnimplementedMomoServiceServer) mustEmbedUnimplementedMomoServiceServer() {}
+func (UnimplementedMomoServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeMomoServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MomoServiceServer will
+// result in compilation errors.
+type UnsafeMomoServiceServer interface {
+	mustEmbedUnimplementedMomoServiceServer()
+}
+
+func RegisterMomoServiceServer(s grpc.ServiceRegistrar, srv MomoServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMomoServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MomoService_ServiceDesc, srv)
+}
+
+func _MomoService_RequestToPay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestToPayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MomoServiceServer).RequestToPay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MomoService_RequestToPay_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MomoServiceServer).RequestToPay(ctx, req.(*RequestToPayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MomoService_GetPaymentStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MomoServiceServer).GetPaymentStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MomoService_GetPaymentStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MomoServiceServer).GetPaymentStatus(ctx, req.(*GetPaymentStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MomoService_GetAccountBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MomoServiceServer).GetAccountBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MomoService_GetAccountBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MomoServiceServer).GetAccountBalance(ctx, req.(*GetAccountBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MomoService_ValidateAccountHolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateAccountHolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MomoServiceServer).ValidateAccountHolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MomoService_ValidateAccountHolder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MomoServiceServer).ValidateAccountHolder(ctx, req.(*ValidateAccountHolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MomoService_WatchPayment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPaymentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MomoServiceServer).WatchPayment(m, &grpc.GenericServerStream[WatchPaymentRequest, PaymentUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MomoService_WatchPaymentServer = grpc.ServerStreamingServer[PaymentUpdate]
+
+// MomoService_ServiceDesc is the grpc.ServiceDesc for MomoService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MomoService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "momo.v1.MomoService",
+	HandlerType: (*MomoServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RequestToPay",
+			Handler:    _MomoService_RequestToPay_Handler,
+		},
+		{
+			MethodName: "GetPaymentStatus",
+			Handler:    _MomoService_GetPaymentStatus_Handler,
+		},
+		{
+			MethodName: "GetAccountBalance",
+			Handler:    _MomoService_GetAccountBalance_Handler,
+		},
+		{
+			MethodName: "ValidateAccountHolder",
+			Handler:    _MomoService_ValidateAccountHolder_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchPayment",
+			Handler:       _MomoService_WatchPayment_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "momo.proto",
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/gin-gonic/gin"
+)
+
+// Portées accordées aux appelants de la passerelle
+const (
+	ScopePaymentsCreate    = "payments:create"
+	ScopePaymentsRead      = "payments:read"
+	ScopeBalanceRead       = "balance:read"
+	ScopeProvisioningAdmin = "provisioning:admin"
+	ScopeWebhooksAdmin     = "webhooks:admin"
+)
+
+// En-tête portant la clé d'un appelant. L'en-tête Authorization est réservé aux JWT.
+const callerKeyHeader = "X-Api-Key"
+
+// Clé du contexte gin sous laquelle l'appelant authentifié est enregistré
+const callerContextKey = "momo.caller"
+
+var (
+	// ErrUnauthenticated signale une requête sans clé ni JWT valide
+	ErrUnauthenticated = errors.New("invalid or missing caller credentials")
+	// ErrForbidden signale un appelant authentifié sans la portée requise
+	ErrForbidden = errors.New("caller lacks the required scope")
+)
+
+// Structure pour l'identité d'un appelant authentifié
+type Caller struct {
+	ID     string   `json:"id"`
+	Scopes []string `json:"scopes"`
+}
+
+// HasScope indique si l'appelant dispose de la portée demandée.
+func (c Caller) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope || s == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Structure pour une clé d'appelant. Seule l'empreinte SHA-256 de la clé est conservée.
+type CallerKey struct {
+	ID     string   `json:"id"`
+	Hash   string   `json:"hash"`
+	Scopes []string `json:"scopes"`
+}
+
+// HashCallerKey renvoie l'empreinte SHA-256, en hexadécimal, d'une clé d'appelant.
+func HashCallerKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewCallerKey génère une clé aléatoire et l'entrée hachée à enregistrer côté passerelle.
+// La clé en clair n'est renvoyée qu'une seule fois.
+func NewCallerKey(id string, scopes ...string) (string, CallerKey, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", CallerKey{}, err
+	}
+	key := "momo_" + hex.EncodeToString(buf)
+	return key, CallerKey{ID: id, Hash: HashCallerKey(key), Scopes: scopes}, nil
+}
+
+// LoadCallerKeys lit un fichier JSON contenant une liste de CallerKey.
+func LoadCallerKeys(path string) ([]CallerKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []CallerKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid caller keys file %s: %w", path, err)
+	}
+	for _, key := range keys {
+		if key.ID == "" || key.Hash == "" {
+			return nil, fmt.Errorf("invalid caller keys file %s: every key needs an id and a hash", path)
+		}
+	}
+	return keys, nil
+}
+
+// Appelant des routes quand l'accès anonyme est autorisé
+var anonymousCaller = Caller{ID: "anonymous", Scopes: []string{"*"}}
+
+// authenticator identifie les appelants de la passerelle et du service gRPC.
+type authenticator struct {
+	keys        map[string]CallerKey
+	jwtSecret   []byte
+	jwtAudience string
+	anonymous   bool
+}
+
+func newAuthenticator(cfg Config) *authenticator {
+	keys := make(map[string]CallerKey, len(cfg.CallerKeys))
+	for _, key := range cfg.CallerKeys {
+		keys[strings.ToLower(key.Hash)] = key
+	}
+	a := &authenticator{keys: keys, jwtSecret: cfg.JWTSecret, jwtAudience: cfg.JWTAudience}
+	a.anonymous = cfg.AllowAnonymous && !a.enabled()
+	return a
+}
+
+// enabled indique si des clés d'appelant ou un secret JWT sont configurés.
+func (a *authenticator) enabled() bool {
+	return len(a.keys) > 0 || len(a.jwtSecret) > 0
+}
+
+// warn journalise au démarrage l'accès anonyme, ou l'absence de tout moyen d'authentification.
+func (a *authenticator) warn(service string) {
+	switch {
+	case a.anonymous:
+		log.Printf("WARNING: anonymous access is allowed, every caller of the %s has all scopes. Never use AllowAnonymous in production.", service)
+	case !a.enabled():
+		log.Printf("Warning: no caller keys or JWT secret configured, every call to the %s is rejected", service)
+	}
+}
+
+// identify reconnaît l'appelant à sa clé, ou à défaut au JWT de la valeur authorization.
+// Sans clé ni secret configurés, l'appelant est anonyme si l'accès anonyme est autorisé,
+// et refusé sinon.
+func (a *authenticator) identify(key, authorization string) (Caller, error) {
+	if a.anonymous {
+		return anonymousCaller, nil
+	}
+	if key != "" {
+		entry, ok := a.keys[HashCallerKey(key)]
+		if !ok {
+			return Caller{}, ErrUnauthenticated
+		}
+		return Caller{ID: entry.ID, Scopes: entry.Scopes}, nil
+	}
+
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if ok && len(a.jwtSecret) > 0 {
+		caller, err := parseJWT(token, a.jwtSecret, a.jwtAudience)
+		if err != nil {
+			return Caller{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+		}
+		return caller, nil
+	}
+	return Caller{}, ErrUnauthenticated
+}
+
+// authenticate identifie l'appelant par sa clé (X-Api-Key) ou par un JWT
+// (Authorization: Bearer). Sans clé ni secret JWT configurés, toutes les requêtes sont
+// refusées, sauf si cfg.AllowAnonymous les traite comme un appelant anonyme disposant
+// de toutes les portées. Un appelant déjà identifié, par un ticket de flux, est conservé.
+func (s *server) authenticate() gin.HandlerFunc {
+	auth := newAuthenticator(s.cfg)
+	auth.warn("gateway")
+
+	return func(c *gin.Context) {
+		if _, ok := c.Get(callerContextKey); ok {
+			c.Next()
+			return
+		}
+		caller, err := auth.identify(c.GetHeader(callerKeyHeader), c.GetHeader("Authorization"))
+		if err != nil {
+			problem(c, httpapi.Problem{Status: http.StatusUnauthorized, Detail: err.Error()})
+			return
+		}
+		setCaller(c, caller)
+		c.Next()
+	}
+}
+
+// requireScope refuse les appelants qui ne disposent pas de la portée demandée.
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if caller := callerFrom(c); !caller.HasScope(scope) {
+			problem(c, httpapi.Problem{Status: http.StatusForbidden, Detail: fmt.Sprintf("%v: %s", ErrForbidden, scope)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// setCaller enregistre l'appelant dans le contexte gin et, pour les handlers httpapi,
+// dans le contexte de la requête.
+func setCaller(c *gin.Context, caller Caller) {
+	c.Set(callerContextKey, caller)
+	c.Request = c.Request.WithContext(httpapi.ContextWithCallerID(c.Request.Context(), caller.ID))
+}
+
+// callerFrom renvoie l'appelant enregistré par authenticate.
+func callerFrom(c *gin.Context) Caller {
+	if value, ok := c.Get(callerContextKey); ok {
+		if caller, ok := value.(Caller); ok {
+			return caller
+		}
+	}
+	return Caller{}
+}
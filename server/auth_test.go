@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/internal/momotest"
+	"github.com/enzoforreal/mtn-momo-api/momopb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func serve(gateway http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+	return rec
+}
+
+func signJWT(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestCallerKeyScopes(t *testing.T) {
+	reader, readerEntry, err := NewCallerKey("reporting", ScopeBalanceRead, ScopePaymentsRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(readerEntry.Hash, reader) {
+		t.Fatal("caller key stored in clear")
+	}
+	gateway, _ := newTestGateway(t, Config{CallerKeys: []CallerKey{readerEntry}})
+
+	if rec := serve(gateway, http.MethodGet, "/get-account-balance", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without caller key, got %d", rec.Code)
+	}
+	if rec := serve(gateway, http.MethodGet, "/get-account-balance", "", map[string]string{"X-Api-Key": "wrong-key"}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 with a wrong caller key, got %d", rec.Code)
+	}
+	if rec := serve(gateway, http.MethodGet, "/get-account-balance", "", map[string]string{"X-Api-Key": reader}); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, map[string]string{"X-Api-Key": reader}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403 without payments:create, got %d", rec.Code)
+	}
+	if rec := serve(gateway, http.MethodPost, "/create-api-user", `{}`, map[string]string{"X-Api-Key": reader}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403 without provisioning:admin, got %d", rec.Code)
+	}
+}
+
+func TestJWTCallerIsRecordedOnPayment(t *testing.T) {
+	gateway, client := newTestGateway(t, Config{JWTSecret: []byte("test-secret")})
+	token := signJWT(t, "test-secret", map[string]interface{}{
+		"sub":   "checkout-service",
+		"scope": "payments:create payments:read",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+	rec := serve(gateway, http.MethodPost, "/request-to-pay", body, map[string]string{"Authorization": "Bearer " + token})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ReferenceID string `json:"reference_id"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+
+	pending, ok, err := client.Pending.Get(context.Background(), created.ReferenceID)
+	if err != nil || !ok {
+		t.Fatalf("expected a pending request, got %v %v", ok, err)
+	}
+	if pending.CallerID != "checkout-service" {
+		t.Fatalf("expected caller checkout-service, got %q", pending.CallerID)
+	}
+
+	forged := signJWT(t, "other-secret", map[string]interface{}{"sub": "intruder", "scope": "payments:create", "exp": time.Now().Add(time.Hour).Unix()})
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, map[string]string{"Authorization": "Bearer " + forged}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 with a forged JWT, got %d", rec.Code)
+	}
+
+	expired := signJWT(t, "test-secret", map[string]interface{}{"sub": "checkout-service", "scope": "payments:create", "exp": time.Now().Add(-time.Minute).Unix()})
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, map[string]string{"Authorization": "Bearer " + expired}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 with an expired JWT, got %d", rec.Code)
+	}
+}
+
+func TestAuthenticationDeniedByDefault(t *testing.T) {
+	gateway := New(Config{}, momotest.NewClient(t))
+	if rec := serve(gateway, http.MethodGet, "/get-account-balance", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without any authentication configured, got %d", rec.Code)
+	}
+	if rec := serve(gateway, http.MethodGet, "/healthz", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected /healthz to stay open, got %d", rec.Code)
+	}
+
+	s := &grpcServer{cfg: Config{}.withDefaults(), auth: newAuthenticator(Config{})}
+	if _, err := s.admit(context.Background(), momopb.MomoService_GetAccountBalance_FullMethodName); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without any authentication configured, got %v", err)
+	}
+
+	gateway = New(Config{AllowAnonymous: true}, momotest.NewClient(t))
+	if rec := serve(gateway, http.MethodGet, "/get-account-balance", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 with anonymous access allowed, got %d", rec.Code)
+	}
+}
+
+func TestJWTAudience(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{JWTSecret: []byte("test-secret"), JWTAudience: "momo-gateway"})
+	for _, tc := range []struct {
+		aud  interface{}
+		want int
+	}{
+		{"momo-gateway", http.StatusOK},
+		{[]string{"billing", "momo-gateway"}, http.StatusOK},
+		{"billing", http.StatusUnauthorized},
+		{nil, http.StatusUnauthorized},
+	} {
+		claims := map[string]interface{}{"sub": "reporting", "scope": ScopeBalanceRead, "exp": time.Now().Add(time.Hour).Unix()}
+		if tc.aud != nil {
+			claims["aud"] = tc.aud
+		}
+		token := signJWT(t, "test-secret", claims)
+		if rec := serve(gateway, http.MethodGet, "/get-account-balance", "", map[string]string{"Authorization": "Bearer " + token}); rec.Code != tc.want {
+			t.Fatalf("expected status %d for audience %v, got %d", tc.want, tc.aud, rec.Code)
+		}
+	}
+}
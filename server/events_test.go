@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaymentEventsAcceptStreamTicket(t *testing.T) {
+	key, entry, err := NewCallerKey("checkout", ScopePaymentsRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gateway, _ := newTestGateway(t, Config{JWTSecret: []byte("test-secret"), CallerKeys: []CallerKey{entry}})
+
+	if rec := serve(gateway, http.MethodGet, "/payments/00000000-0000-4000-8000-000000000001/events", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without a ticket, got %d", rec.Code)
+	}
+	// Les identifiants de l'appelant ne sont jamais acceptés dans l'URL
+	token := signJWT(t, "test-secret", map[string]interface{}{"sub": "checkout-page", "scope": "payments:read", "exp": time.Now().Add(time.Minute).Unix()})
+	for _, credential := range []string{key, token} {
+		if rec := serve(gateway, http.MethodGet, "/payments/00000000-0000-4000-8000-000000000001/events?ticket="+credential, "", nil); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected caller credentials to be refused in the URL, got %d", rec.Code)
+		}
+	}
+
+	rec := serve(gateway, http.MethodPost, "/payments/00000000-0000-4000-8000-000000000001/stream-ticket", "", map[string]string{"Authorization": "Bearer " + token})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var issued struct {
+		Ticket string `json:"ticket"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &issued)
+
+	if rec := serve(gateway, http.MethodGet, "/payments/00000000-0000-4000-8000-000000000002/events?ticket="+issued.Ticket, "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the ticket to be refused for another payment, got %d", rec.Code)
+	}
+
+	rec = serve(gateway, http.MethodPost, "/payments/00000000-0000-4000-8000-000000000001/stream-ticket", "", map[string]string{"X-Api-Key": key})
+	json.Unmarshal(rec.Body.Bytes(), &issued)
+	rec = serve(gateway, http.MethodGet, "/payments/00000000-0000-4000-8000-000000000001/events?ticket="+issued.Ticket, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	// Le faux MoMo déclare le paiement SUCCESSFUL : le flux se termine après un seul événement
+	if body := rec.Body.String(); !strings.Contains(body, "id: SUCCESSFUL\nevent: status\n") {
+		t.Fatalf("expected a final status event, got %q", body)
+	}
+
+	if rec := serve(gateway, http.MethodGet, "/payments/00000000-0000-4000-8000-000000000001/events?ticket="+issued.Ticket, "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the ticket to be single-use, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,402 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/enzoforreal/mtn-momo-api/momopb"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Métadonnées des appels gRPC, équivalentes aux en-têtes de la passerelle HTTP
+const (
+	grpcKeyMetadata         = "x-api-key"
+	grpcAuthMetadata        = "authorization"
+	grpcCorrelationMetadata = "x-correlation-id"
+)
+
+// Domaine des erreurs gRPC renvoyées avec un errdetails.ErrorInfo
+const grpcErrorDomain = "momo"
+
+// Portée requise par chaque méthode du service gRPC
+var grpcScopes = map[string]string{
+	momopb.MomoService_RequestToPay_FullMethodName:          ScopePaymentsCreate,
+	momopb.MomoService_GetPaymentStatus_FullMethodName:      ScopePaymentsRead,
+	momopb.MomoService_GetAccountBalance_FullMethodName:     ScopeBalanceRead,
+	momopb.MomoService_ValidateAccountHolder_FullMethodName: ScopePaymentsCreate,
+	momopb.MomoService_WatchPayment_FullMethodName:          ScopePaymentsRead,
+}
+
+type grpcServer struct {
+	momopb.UnimplementedMomoServiceServer
+
+	cfg    Config
+	client *momo.Client
+	hub    *httpapi.StatusHub
+	auth   *authenticator
+}
+
+// NewGRPC construit le service gRPC momo.v1.MomoService autour du même client MoMo que New.
+// Les appelants s'authentifient avec les mêmes clés et JWT, transmis dans les métadonnées
+// x-api-key et authorization, et disposent des mêmes portées. Les limites par appelant et
+// par payeur s'appliquent aussi ; les routes de RateLimits.Routes sont désignées par le nom
+// complet de la méthode, par exemple /momo.v1.MomoService/RequestToPay.
+// Les erreurs sont traduites comme celles de l'API REST, puis en code gRPC.
+// Pour que les limites et /metrics couvrent les deux transports, cfg doit porter les mêmes
+// Metrics, RateLimits.Store et Idempotency que la configuration passée à New.
+func NewGRPC(cfg Config, client *momo.Client, opts ...grpc.ServerOption) *grpc.Server {
+	cfg = cfg.withDefaults()
+	if client.Metrics == nil {
+		client.Metrics = cfg.Metrics
+	}
+	if client.TracerProvider == nil {
+		client.TracerProvider = cfg.TracerProvider
+	}
+	s := &grpcServer{
+		cfg:    cfg,
+		client: client,
+		hub:    cfg.statusHub(client),
+		auth:   newAuthenticator(cfg),
+	}
+	s.auth.warn("gRPC service")
+
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	)
+	srv := grpc.NewServer(opts...)
+	momopb.RegisterMomoServiceServer(srv, s)
+	return srv
+}
+
+func (s *grpcServer) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.admit(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *grpcServer) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.admit(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+}
+
+// contextStream remplace le contexte d'un flux par celui enrichi par admit.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// admit attribue un identifiant de corrélation à l'appel, identifie l'appelant, vérifie
+// sa portée et sa limite de débit.
+func (s *grpcServer) admit(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	correlationID := firstMetadata(md, grpcCorrelationMetadata)
+	if correlationID == "" || len(correlationID) > 128 {
+		correlationID = uuid.New().String()
+	}
+	ctx = httpapi.ContextWithCorrelationID(ctx, correlationID)
+	grpc.SetHeader(ctx, metadata.Pairs(grpcCorrelationMetadata, correlationID))
+
+	caller, err := s.auth.identify(firstMetadata(md, grpcKeyMetadata), firstMetadata(md, grpcAuthMetadata))
+	if err != nil {
+		return ctx, grpcProblem(ctx, httpapi.Problem{Status: http.StatusUnauthorized, Detail: err.Error()})
+	}
+	ctx = httpapi.ContextWithCallerID(ctx, caller.ID)
+
+	scope, ok := grpcScopes[method]
+	if !ok {
+		return ctx, status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	if !caller.HasScope(scope) {
+		return ctx, grpcProblem(ctx, httpapi.Problem{Status: http.StatusForbidden, Detail: fmt.Sprintf("%v: %s", ErrForbidden, scope)})
+	}
+
+	limit, ok := s.cfg.RateLimits.Routes[method]
+	if !ok {
+		limit = s.cfg.RateLimits.PerCaller
+	}
+	return ctx, s.allow(ctx, "caller|"+caller.ID+"|"+method, limit, "caller")
+}
+
+// allow consomme un jeton, ou renvoie ResourceExhausted avec le délai d'attente.
+// Si le store est indisponible, l'appel est laissé passer.
+func (s *grpcServer) allow(ctx context.Context, key string, limit Limit, scope string) error {
+	if !limit.Enabled() {
+		return nil
+	}
+	allowed, retryAfter, err := s.cfg.RateLimits.Store.Allow(ctx, key, limit)
+	if err != nil {
+		log.Printf("Error checking rate limit for %s: %v", key, err)
+		return nil
+	}
+	if allowed {
+		return nil
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("Rate limit exceeded for this %s, retry in %d seconds", scope, seconds))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Duration(seconds) * time.Second)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func (s *grpcServer) RequestToPay(ctx context.Context, req *momopb.RequestToPayRequest) (*momopb.RequestToPayResponse, error) {
+	request := momo.RequestToPay{
+		Amount:       req.GetAmount(),
+		Currency:     req.GetCurrency(),
+		ExternalId:   req.GetExternalId(),
+		Payer:        partyFromProto(req.GetPayer()),
+		PayerMessage: req.GetPayerMessage(),
+		PayeeNote:    req.GetPayeeNote(),
+		CallbackURL:  req.GetCallbackUrl(),
+		CallerID:     httpapi.CallerID(ctx),
+	}
+	if err := request.Validate(); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	if err := s.allow(ctx, "payer|"+request.Payer.PartyId, s.cfg.RateLimits.PerPayer, "payer"); err != nil {
+		return nil, err
+	}
+
+	referenceID, err := s.client.Collection().WithContext(ctx).RequestToPay(request)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	log.Printf("Payment request %s created by caller %s", referenceID, request.CallerID)
+	return &momopb.RequestToPayResponse{ReferenceId: referenceID}, nil
+}
+
+func (s *grpcServer) GetPaymentStatus(ctx context.Context, req *momopb.GetPaymentStatusRequest) (*momopb.Payment, error) {
+	if req.GetReferenceId() == "" {
+		return nil, grpcProblem(ctx, httpapi.Problem{Status: http.StatusBadRequest, Detail: "Reference ID is required"})
+	}
+	if err := momo.ValidateReferenceID(req.GetReferenceId()); err != nil {
+		return nil, grpcProblem(ctx, httpapi.Problem{Status: http.StatusBadRequest, Detail: err.Error()})
+	}
+	result, err := s.client.Collection().WithContext(ctx).GetPaymentStatus(req.GetReferenceId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return paymentToProto(req.GetReferenceId(), result), nil
+}
+
+func (s *grpcServer) GetAccountBalance(ctx context.Context, _ *momopb.GetAccountBalanceRequest) (*momopb.Balance, error) {
+	balance, err := s.client.Collection().WithContext(ctx).GetAccountBalance()
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &momopb.Balance{AvailableBalance: balance.AvailableBalance, Currency: balance.Currency}, nil
+}
+
+func (s *grpcServer) ValidateAccountHolder(ctx context.Context, req *momopb.ValidateAccountHolderRequest) (*momopb.ValidateAccountHolderResponse, error) {
+	active, err := s.client.Collection().WithContext(ctx).ValidateAccountHolder(partyFromProto(req.GetAccountHolder()))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &momopb.ValidateAccountHolderResponse{Active: active}, nil
+}
+
+func (s *grpcServer) WatchPayment(req *momopb.WatchPaymentRequest, stream momopb.MomoService_WatchPaymentServer) error {
+	ctx := stream.Context()
+	if req.GetReferenceId() == "" {
+		return grpcProblem(ctx, httpapi.Problem{Status: http.StatusBadRequest, Detail: "Reference ID is required"})
+	}
+	if err := momo.ValidateReferenceID(req.GetReferenceId()); err != nil {
+		return grpcProblem(ctx, httpapi.Problem{Status: http.StatusBadRequest, Detail: err.Error()})
+	}
+	updates, err := s.hub.Watch(ctx, req.GetReferenceId())
+	if err != nil {
+		return grpcError(ctx, err)
+	}
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return ctx.Err()
+			}
+			err := stream.Send(&momopb.PaymentUpdate{
+				ReferenceId: update.ReferenceID,
+				Status:      update.Status,
+				Source:      update.Source,
+				Payment:     paymentToProto(update.ReferenceID, update.Result),
+				At:          timestamppb.New(update.At),
+			})
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func partyFromProto(party *momopb.Party) momo.Party {
+	return momo.Party{PartyIdType: party.GetPartyIdType(), PartyId: party.GetPartyId()}
+}
+
+func paymentToProto(referenceID string, result *momo.RequestToPayResult) *momopb.Payment {
+	if result == nil {
+		return nil
+	}
+	payment := &momopb.Payment{
+		ReferenceId:            result.ReferenceId,
+		Status:                 result.Status,
+		Amount:                 result.Amount,
+		Currency:               result.Currency,
+		FinancialTransactionId: result.FinancialTransactionId,
+		ExternalId:             result.ExternalId,
+		Payer:                  &momopb.Party{PartyIdType: result.Payer.PartyIdType, PartyId: result.Payer.PartyId},
+		PayerMessage:           result.PayerMessage,
+		PayeeNote:              result.PayeeNote,
+	}
+	if payment.ReferenceId == "" {
+		payment.ReferenceId = referenceID
+	}
+	if result.Reason != nil {
+		payment.Reason = &momopb.Reason{Code: result.Reason.Code, Message: result.Reason.Message}
+	}
+	return payment
+}
+
+// grpcError traduit une erreur du client MoMo comme httpapi.ErrorProblem, puis en statut gRPC.
+func grpcError(ctx context.Context, err error) error {
+	return grpcProblem(ctx, httpapi.ErrorProblem(err))
+}
+
+// grpcProblem convertit un Problem en statut gRPC. Les champs invalides sont joints dans un
+// errdetails.BadRequest ; le code MoMo et l'identifiant de corrélation dans un errdetails.ErrorInfo.
+func grpcProblem(ctx context.Context, p httpapi.Problem) error {
+	correlationID := httpapi.CorrelationID(ctx)
+	log.Printf("Error [%s] %d %s: %s", correlationID, p.Status, p.Code, p.Detail)
+
+	st := status.New(grpcCode(p.Status), p.Detail)
+	var details []protoadapt.MessageV1
+	if len(p.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(p.Fields))
+		for i, field := range p.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+	if p.Code != "" || correlationID != "" {
+		reason := p.Code
+		if reason == "" {
+			reason = http.StatusText(p.Status)
+		}
+		details = append(details, &errdetails.ErrorInfo{
+			Reason:   reason,
+			Domain:   grpcErrorDomain,
+			Metadata: map[string]string{"correlation_id": correlationID},
+		})
+	}
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// grpcCode choisit le code gRPC correspondant au statut HTTP de la passerelle.
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ListenAndServeGRPC sert srv sur cfg.GRPCAddr jusqu'à l'annulation de ctx ou la réception de
+// SIGINT/SIGTERM, puis attend les appels en cours pendant cfg.ShutdownTimeout avant de
+// les interrompre.
+func ListenAndServeGRPC(ctx context.Context, cfg Config, srv *grpc.Server) error {
+	cfg = cfg.withDefaults()
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		return err
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("gRPC service listening on %s", cfg.GRPCAddr)
+		errc <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down gRPC service...")
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(cfg.ShutdownTimeout):
+		srv.Stop()
+	}
+	if err := <-errc; err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	log.Println("gRPC service stopped")
+	return nil
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/internal/momotest"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/enzoforreal/mtn-momo-api/momopb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPC démarre un service gRPC de test. Comme newTestGateway, il est anonyme sans
+// clé ni secret JWT dans cfg.
+func newTestGRPC(t *testing.T, cfg Config) momopb.MomoServiceClient {
+	cfg.AllowAnonymous = true
+	client := momotest.NewClient(t)
+	client.Pending = momo.NewMemoryPendingStore()
+	srv := NewGRPC(cfg, client)
+
+	listener := bufconn.Listen(1 << 20)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return momopb.NewMomoServiceClient(conn)
+}
+
+func TestGRPCPaymentFlow(t *testing.T) {
+	client := newTestGRPC(t, Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var header metadata.MD
+	created, err := client.RequestToPay(ctx, &momopb.RequestToPayRequest{
+		Amount:     "100",
+		Currency:   "EUR",
+		ExternalId: "123456",
+		Payer:      &momopb.Party{PartyIdType: momo.PartyIdTypeMSISDN, PartyId: "46733123453"},
+	}, grpc.Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.GetReferenceId() == "" {
+		t.Fatal("expected a reference ID")
+	}
+	if len(header.Get(grpcCorrelationMetadata)) == 0 {
+		t.Error("expected a correlation ID in the response header")
+	}
+
+	payment, err := client.GetPaymentStatus(ctx, &momopb.GetPaymentStatusRequest{ReferenceId: created.GetReferenceId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.GetStatus() != momo.StatusSuccessful || payment.GetReferenceId() != created.GetReferenceId() {
+		t.Fatalf("unexpected payment %v", payment)
+	}
+
+	balance, err := client.GetAccountBalance(ctx, &momopb.GetAccountBalanceRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.GetAvailableBalance() != "1000" || balance.GetCurrency() != "EUR" {
+		t.Fatalf("unexpected balance %v", balance)
+	}
+
+	holder, err := client.ValidateAccountHolder(ctx, &momopb.ValidateAccountHolderRequest{
+		AccountHolder: &momopb.Party{PartyIdType: momo.PartyIdTypeMSISDN, PartyId: "46733123453"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !holder.GetActive() {
+		t.Fatal("expected the account holder to be active")
+	}
+
+	// Le faux MoMo déclare le paiement SUCCESSFUL : le flux se termine après une seule mise à jour
+	stream, err := client.WatchPayment(ctx, &momopb.WatchPaymentRequest{ReferenceId: created.GetReferenceId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	update, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.GetStatus() != momo.StatusSuccessful || update.GetPayment().GetReferenceId() != created.GetReferenceId() {
+		t.Fatalf("unexpected update %v", update)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the stream to end after the final status, got %v", err)
+	}
+}
+
+func TestGRPCAuthenticationAndErrors(t *testing.T) {
+	key, entry, err := NewCallerKey("reporting", ScopePaymentsRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestGRPC(t, Config{JWTSecret: []byte("test-secret"), CallerKeys: []CallerKey{entry}})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = client.GetAccountBalance(ctx, &momopb.GetAccountBalanceRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without credentials, got %v", err)
+	}
+
+	keyCtx := metadata.AppendToOutgoingContext(ctx, grpcKeyMetadata, key)
+	if _, err := client.GetPaymentStatus(keyCtx, &momopb.GetPaymentStatusRequest{ReferenceId: "00000000-0000-4000-8000-000000000001"}); err != nil {
+		t.Fatalf("expected payments:read to be enough, got %v", err)
+	}
+	_, err = client.RequestToPay(keyCtx, &momopb.RequestToPayRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied without payments:create, got %v", err)
+	}
+
+	token := signJWT(t, "test-secret", map[string]interface{}{"sub": "checkout", "scope": "payments:create", "exp": time.Now().Add(time.Minute).Unix()})
+	jwtCtx := metadata.AppendToOutgoingContext(ctx, grpcAuthMetadata, "Bearer "+token)
+	_, err = client.RequestToPay(jwtCtx, &momopb.RequestToPayRequest{Amount: "ten"})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an invalid payment, got %v", err)
+	}
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = badRequest.GetFieldViolations()
+		}
+	}
+	if len(violations) == 0 || violations[0].GetField() != "amount" {
+		t.Fatalf("expected field violations starting with amount, got %v", violations)
+	}
+}
+
+func TestGRPCRejectsInvalidReferenceID(t *testing.T) {
+	client := newTestGRPC(t, Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.GetPaymentStatus(ctx, &momopb.GetPaymentStatusRequest{ReferenceId: "../../v1_0/accountholder/msisdn/123/basicuserinfo"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	stream, err := client.WatchPayment(ctx, &momopb.WatchPaymentRequest{ReferenceId: "../ref"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGRPCCodes(t *testing.T) {
+	for httpStatus, code := range map[int]codes.Code{
+		400: codes.InvalidArgument,
+		404: codes.NotFound,
+		409: codes.AlreadyExists,
+		422: codes.InvalidArgument,
+		500: codes.Internal,
+		502: codes.Unavailable,
+		503: codes.Unavailable,
+		504: codes.DeadlineExceeded,
+	} {
+		if got := grpcCode(httpStatus); got != code {
+			t.Errorf("grpcCode(%d) = %v, expected %v", httpStatus, got, code)
+		}
+	}
+}
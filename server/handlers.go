@@ -0,0 +1,98 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (s *server) createAPIUserHandler(c *gin.Context) {
+	var req struct {
+		ReferenceID  string `json:"reference_id"`
+		CallbackHost string `json:"callback_host"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	if req.ReferenceID == "" {
+		req.ReferenceID = uuid.New().String()
+	}
+
+	log.Printf("Creating API user with reference ID %s and callback host %s", req.ReferenceID, req.CallbackHost)
+	if err := s.client.CreateAPIUser(req.ReferenceID, req.CallbackHost); err != nil {
+		fail(c, err)
+		return
+	}
+
+	log.Println("API user created successfully")
+	c.JSON(http.StatusCreated, gin.H{"message": "API user created successfully", "reference_id": req.ReferenceID})
+}
+
+func (s *server) createAPIKeyHandler(c *gin.Context) {
+	var req struct {
+		ReferenceID string `json:"reference_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	if req.ReferenceID == "" {
+		badRequest(c, "Reference ID is required")
+		return
+	}
+
+	log.Printf("Creating API key for reference ID %s", req.ReferenceID)
+	apiKey, err := s.client.CreateAPIKey(req.ReferenceID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	log.Println("API key created successfully")
+	c.JSON(http.StatusCreated, gin.H{"api_key": apiKey})
+}
+
+func (s *server) getAPIUserHandler(c *gin.Context) {
+	referenceID := c.Param("reference_id")
+	if referenceID == "" {
+		badRequest(c, "Reference ID is required")
+		return
+	}
+	if err := momo.ValidateReferenceID(referenceID); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	apiUser, err := s.client.GetAPIUser(c.Request.Context(), referenceID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	log.Printf("API user %s retrieved successfully", referenceID)
+	c.JSON(http.StatusOK, gin.H{
+		"reference_id":           referenceID,
+		"provider_callback_host": apiUser.ProviderCallbackHost,
+		"target_environment":     apiUser.TargetEnvironment,
+	})
+}
+
+// getAuthTokenHandler renouvelle le token de collection gardé par la passerelle.
+// Le token lui-même n'est jamais renvoyé à l'appelant.
+func (s *server) getAuthTokenHandler(c *gin.Context) {
+	collection := s.client.Collection().WithContext(c.Request.Context())
+	collection.InvalidateToken()
+	if _, err := collection.Token(); err != nil {
+		fail(c, err)
+		return
+	}
+
+	log.Println("Token refreshed successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Token refreshed successfully", "expires_at": collection.TokenExpiry()})
+}
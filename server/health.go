@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/gin-gonic/gin"
+)
+
+var errMissingCredentials = errors.New("collection credentials are not configured")
+
+// Délai accordé à chaque vérification de /readyz
+const readinessTimeout = 5 * time.Second
+
+// Pinger est implémenté par les stores partagés (idempotence, limitation de débit,
+// paiements en attente) pour que /readyz vérifie qu'ils sont joignables. Les stores
+// qui ne l'implémentent pas, comme les stores en mémoire, sont considérés disponibles.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Structure pour la réponse de /readyz
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Structure pour le token en cache d'un produit, dans /debug/momo
+type tokenInfo struct {
+	Cached    bool       `json:"cached"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	ExpiresIn string     `json:"expiresIn,omitempty"`
+}
+
+// Structure pour la réponse de /debug/momo
+type diagnostics struct {
+	Environment string                     `json:"environment"`
+	BaseURL     string                     `json:"baseUrl"`
+	Tokens      map[momo.Product]tokenInfo `json:"tokens"`
+	Upstream    []momo.OperationStats      `json:"upstream"`
+	RecentCalls []momo.UpstreamCall        `json:"recentCalls"`
+}
+
+// healthzHandler indique que le processus répond.
+func (s *server) healthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyzHandler vérifie que les identifiants sont chargés, qu'un token peut être obtenu
+// (en cache si possible) et que les stores configurés sont joignables.
+func (s *server) readyzHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	result := readiness{Status: "ready", Checks: make(map[string]string)}
+	check := func(name string, err error) {
+		if err != nil {
+			result.Status = "not ready"
+			result.Checks[name] = err.Error()
+			return
+		}
+		result.Checks[name] = "ok"
+	}
+
+	collection := s.client.Collection().WithContext(ctx)
+	creds := collection.Credentials
+	if creds.ApiUserID == "" || creds.ApiKey == "" || creds.SubscriptionKey == "" {
+		check("credentials", errMissingCredentials)
+		check("token", errMissingCredentials)
+	} else {
+		check("credentials", nil)
+		_, err := collection.Token()
+		check("token", err)
+	}
+
+	stores := map[string]interface{}{
+		"idempotency": s.cfg.Idempotency,
+		"rateLimit":   s.cfg.RateLimits.Store,
+		"pending":     s.client.Pending,
+	}
+	for name, store := range stores {
+		if pinger, ok := store.(Pinger); ok {
+			check(name, pinger.Ping(ctx))
+		}
+	}
+
+	status := http.StatusOK
+	if result.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, result)
+}
+
+// debugHandler décrit la connexion à MoMo : environnement, URL, tokens en cache,
+// latence et erreurs des derniers appels.
+func (s *server) debugHandler(c *gin.Context) {
+	result := diagnostics{
+		Environment: s.client.Environment,
+		BaseURL:     s.client.Endpoint(),
+		Tokens:      make(map[momo.Product]tokenInfo),
+		Upstream:    s.client.UpstreamStats(),
+		RecentCalls: s.client.RecentCalls(),
+	}
+
+	products := map[momo.Product]*momo.ProductClient{
+		momo.ProductCollection:   s.client.Collection().ProductClient,
+		momo.ProductDisbursement: s.client.Disbursement().ProductClient,
+		momo.ProductRemittance:   s.client.Remittance().ProductClient,
+	}
+	for product, p := range products {
+		info := tokenInfo{}
+		if expiry := p.TokenExpiry(); !expiry.IsZero() {
+			info.Cached = true
+			info.ExpiresAt = &expiry
+			info.ExpiresIn = time.Until(expiry).Round(time.Second).String()
+		}
+		result.Tokens[product] = info
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+)
+
+// unreachableStore est un store d'idempotence partagé qui ne répond plus.
+type unreachableStore struct {
+	*MemoryIdempotencyStore
+}
+
+func (unreachableStore) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+
+	if rec := serve(gateway, http.MethodGet, "/healthz", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 from /healthz, got %d", rec.Code)
+	}
+
+	rec := serve(gateway, http.MethodGet, "/readyz", "", nil)
+	var ready readiness
+	json.Unmarshal(rec.Body.Bytes(), &ready)
+	if rec.Code != http.StatusOK || ready.Checks["token"] != "ok" || ready.Checks["credentials"] != "ok" {
+		t.Fatalf("expected the gateway to be ready, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	down, _ := newTestGateway(t, Config{Idempotency: unreachableStore{NewMemoryIdempotencyStore()}})
+	rec = serve(down, http.MethodGet, "/readyz", "", nil)
+	json.Unmarshal(rec.Body.Bytes(), &ready)
+	if rec.Code != http.StatusServiceUnavailable || ready.Checks["idempotency"] != "connection refused" {
+		t.Fatalf("expected the gateway not to be ready, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDebugReportsUpstreamCalls(t *testing.T) {
+	reader, readerEntry, err := NewCallerKey("ops", ScopePaymentsRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, adminEntry, err := NewCallerKey("admin", ScopeProvisioningAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gateway, client := newTestGateway(t, Config{CallerKeys: []CallerKey{readerEntry, adminEntry}})
+
+	if rec := serve(gateway, http.MethodGet, "/debug/momo", "", map[string]string{"X-Api-Key": reader}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403 without the admin scope, got %d", rec.Code)
+	}
+
+	if _, err := client.Collection().GetPaymentStatus("ref-1"); err != nil {
+		t.Fatal(err)
+	}
+	rec := serve(gateway, http.MethodGet, "/debug/momo", "", map[string]string{"X-Api-Key": admin})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var debug diagnostics
+	json.Unmarshal(rec.Body.Bytes(), &debug)
+	if debug.Environment != "sandbox" || debug.BaseURL != client.BaseURL {
+		t.Fatalf("unexpected environment %q and base URL %q", debug.Environment, debug.BaseURL)
+	}
+	if !debug.Tokens[momo.ProductCollection].Cached || debug.Tokens[momo.ProductDisbursement].Cached {
+		t.Fatalf("expected only the collection token to be cached, got %+v", debug.Tokens)
+	}
+	if len(debug.Upstream) != 2 || debug.Upstream[1].Operation != "get payment status" || debug.Upstream[1].Calls != 1 {
+		t.Fatalf("unexpected upstream stats %+v", debug.Upstream)
+	}
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/gin-gonic/gin"
+)
+
+// En-têtes d'idempotence
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+// Durée de rétention par défaut des clés d'idempotence
+const defaultIdempotencyRetention = 24 * time.Hour
+
+// Taille maximale acceptée pour une clé d'idempotence
+const maxIdempotencyKeyLength = 255
+
+// Structure pour une requête enregistrée sous une clé d'idempotence
+type IdempotencyRecord struct {
+	Key         string    `json:"key"`
+	Fingerprint string    `json:"fingerprint"`
+	Completed   bool      `json:"completed"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Body        []byte    `json:"body,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// IdempotencyStore conserve les réponses associées aux clés d'idempotence.
+//
+// Begin réserve la clé de record de façon atomique. Si la clé existe déjà et n'a pas
+// expiré, l'enregistrement existant est renvoyé avec false. Complete enregistre la
+// réponse ; Release libère une clé dont la requête a échoué pour permettre un nouvel essai.
+type IdempotencyStore interface {
+	Begin(ctx context.Context, record IdempotencyRecord) (IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, record IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryIdempotencyStore est un IdempotencyStore en mémoire, limité à un seul processus.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+}
+
+var _ IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]IdempotencyRecord)}
+}
+
+func (s *MemoryIdempotencyStore) Begin(ctx context.Context, record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, existing := range s.records {
+		if now.After(existing.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+	if existing, ok := s.records[record.Key]; ok {
+		return existing, false, nil
+	}
+	s.records[record.Key] = record
+	return record, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.Key] = record
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// idempotent rejoue la réponse d'origine quand une requête est renvoyée avec le même
+// Idempotency-Key et le même corps. Un corps différent, ou une requête encore en cours,
+// renvoie un 409. Une réponse 5xx n'est pas conservée, pour permettre un nouvel essai,
+// si l'erreur est survenue avant l'envoi à MoMo ; si l'issue de l'envoi est inconnue
+// (délai dépassé, 502, 503), elle est conservée pour ne pas créer un second paiement.
+// Une réponse d'un middleware suivant qui interrompt la requête, comme le 429 de
+// rateLimitPayer, ne vient pas du handler : la clé est libérée. Les clés sont propres
+// à chaque appelant.
+func (s *server) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			badRequest(c, idempotencyKeyHeader+" header is too long")
+			return
+		}
+
+		body, ok := readBody(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		record := IdempotencyRecord{
+			Key:         callerFrom(c).ID + "|" + c.FullPath() + "|" + key,
+			Fingerprint: fingerprint(c.Request.Method, c.FullPath(), body),
+			ExpiresAt:   time.Now().Add(s.cfg.IdempotencyRetention),
+		}
+		existing, created, err := s.cfg.Idempotency.Begin(ctx, record)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		if !created {
+			s.replay(c, key, record, existing)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if c.IsAborted() || (recorder.Status() >= http.StatusInternalServerError && !outcomeUnknown(recorder.body.Bytes())) {
+			if err := s.cfg.Idempotency.Release(ctx, record.Key); err != nil {
+				log.Printf("Error releasing idempotency key %s: %v", key, err)
+			}
+			return
+		}
+		record.Completed = true
+		record.StatusCode = recorder.Status()
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.Body = recorder.body.Bytes()
+		if err := s.cfg.Idempotency.Complete(ctx, record); err != nil {
+			log.Printf("Error storing idempotency key %s: %v", key, err)
+		}
+	}
+}
+
+func (s *server) replay(c *gin.Context, key string, record, existing IdempotencyRecord) {
+	switch {
+	case existing.Fingerprint != record.Fingerprint:
+		problem(c, httpapi.Problem{Status: http.StatusConflict, Detail: idempotencyKeyHeader + " was already used with a different request body"})
+	case !existing.Completed:
+		problem(c, httpapi.Problem{Status: http.StatusConflict, Detail: "A request with this " + idempotencyKeyHeader + " is still in progress"})
+	default:
+		log.Printf("Replaying response for %s %s", idempotencyKeyHeader, key)
+		c.Header(idempotencyReplayedHeader, "true")
+		c.Data(existing.StatusCode, existing.ContentType, existing.Body)
+	}
+	c.Abort()
+}
+
+// outcomeUnknown indique si une réponse d'erreur porte la référence d'une opération
+// peut-être acceptée par MoMo (httpapi.Problem.ReferenceID).
+func outcomeUnknown(body []byte) bool {
+	var p httpapi.Problem
+	return json.Unmarshal(body, &p) == nil && p.ReferenceID != ""
+}
+
+func fingerprint(method, route string, body []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(method + " " + route + "\n"))
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// responseRecorder copie le corps de la réponse pour pouvoir la rejouer.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(data string) (int, error) {
+	r.body.WriteString(data)
+	return r.ResponseWriter.WriteString(data)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+)
+
+func TestIdempotencyKeyReplaysResponse(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+	headers := map[string]string{"Idempotency-Key": "order-123456"}
+
+	first := serve(gateway, http.MethodPost, "/request-to-pay", body, headers)
+	if first.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", first.Code, first.Body.String())
+	}
+	retry := serve(gateway, http.MethodPost, "/request-to-pay", body, headers)
+	if retry.Code != http.StatusAccepted {
+		t.Fatalf("expected replayed status 202, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("expected the Idempotent-Replayed header on the retry")
+	}
+
+	var created, replayed struct {
+		ReferenceID string `json:"reference_id"`
+	}
+	json.Unmarshal(first.Body.Bytes(), &created)
+	json.Unmarshal(retry.Body.Bytes(), &replayed)
+	if created.ReferenceID == "" || replayed.ReferenceID != created.ReferenceID {
+		t.Fatalf("expected the same reference ID, got %q and %q", created.ReferenceID, replayed.ReferenceID)
+	}
+
+	other := serve(gateway, http.MethodPost, "/request-to-pay", body, map[string]string{"Idempotency-Key": "order-654321"})
+	var fresh struct {
+		ReferenceID string `json:"reference_id"`
+	}
+	json.Unmarshal(other.Body.Bytes(), &fresh)
+	if fresh.ReferenceID == created.ReferenceID {
+		t.Fatal("expected a new reference ID for another idempotency key")
+	}
+}
+
+func TestIdempotencyKeyConflict(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+	headers := map[string]string{"Idempotency-Key": "order-123456"}
+
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, headers); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", rec.Code)
+	}
+
+	changed := `{"amount": "200", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", changed, headers); rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 for a different body, got %d", rec.Code)
+	}
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	ctx := context.Background()
+
+	record := IdempotencyRecord{Key: "key", Fingerprint: "a", ExpiresAt: time.Now().Add(-time.Second)}
+	if _, created, _ := store.Begin(ctx, record); !created {
+		t.Fatal("expected the key to be reserved")
+	}
+
+	record.Fingerprint = "b"
+	record.ExpiresAt = time.Now().Add(time.Hour)
+	existing, created, _ := store.Begin(ctx, record)
+	if !created || existing.Fingerprint != "b" {
+		t.Fatal("expected the expired key to be reserved again")
+	}
+}
+
+func TestIdempotencyKeyKeptWhenOutcomeIsUnknown(t *testing.T) {
+	tokenStatus, paymentStatus := http.StatusServiceUnavailable, http.StatusAccepted
+	payments := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/collection/token/":
+			if tokenStatus != http.StatusOK {
+				w.WriteHeader(tokenStatus)
+				return
+			}
+			json.NewEncoder(w).Encode(momo.AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+		case "/collection/v1_0/requesttopay":
+			payments++
+			w.WriteHeader(paymentStatus)
+		}
+	}))
+	defer upstream.Close()
+
+	client := momo.NewClientFromConfig(momo.Config{
+		Environment: "sandbox",
+		BaseURL:     upstream.URL,
+		Collection:  momo.Credentials{SubscriptionKey: "test-subscription-key", ApiUserID: "test-api-user-id", ApiKey: "test-api-key"},
+	})
+	gateway := New(Config{AllowAnonymous: true}, client)
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+
+	// Sans token, rien n'a été envoyé à MoMo : la clé est libérée
+	headers := map[string]string{"Idempotency-Key": "order-1"}
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, headers); rec.Code < http.StatusInternalServerError {
+		t.Fatalf("expected a 5xx without a token, got %d", rec.Code)
+	}
+	tokenStatus = http.StatusOK
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, headers); rec.Code != http.StatusAccepted || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("expected a new attempt to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Un 503 de MoMo laisse l'issue inconnue : la réponse est rejouée, sans nouvel envoi
+	paymentStatus = http.StatusServiceUnavailable
+	headers = map[string]string{"Idempotency-Key": "order-2"}
+	first := serve(gateway, http.MethodPost, "/request-to-pay", body, headers)
+	var p httpapi.Problem
+	json.Unmarshal(first.Body.Bytes(), &p)
+	if first.Code < http.StatusInternalServerError || p.ReferenceID == "" {
+		t.Fatalf("expected a 5xx with the reference ID, got %d: %s", first.Code, first.Body.String())
+	}
+	sent := payments
+	paymentStatus = http.StatusAccepted
+	retry := serve(gateway, http.MethodPost, "/request-to-pay", body, headers)
+	if retry.Code != first.Code || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected the unknown outcome to be replayed, got %d", retry.Code)
+	}
+	if payments != sent {
+		t.Fatal("expected no second payment request to MoMo")
+	}
+}
+
+func TestIdempotentBodyIsBounded(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+	body := `{"externalId": "` + strings.Repeat("x", maxRequestBodySize) + `"}`
+	rec := serve(gateway, http.MethodPost, "/request-to-pay", body, map[string]string{"Idempotency-Key": "order-123456"})
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413, got %d", rec.Code)
+	}
+}
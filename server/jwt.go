@@ -0,0 +1,104 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Structure pour les claims lus dans un JWT d'appelant
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Scope     string          `json:"scope"`
+	Scopes    []string        `json:"scopes"`
+	ExpiresAt int64           `json:"exp"`
+	NotBefore int64           `json:"nbf"`
+	Audience  json.RawMessage `json:"aud"`
+}
+
+// parseJWT vérifie un JWT signé en HS256 et renvoie l'appelant qu'il décrit.
+// Les portées sont lues dans le claim scope (séparées par des espaces) ou scopes (liste).
+// Si audience n'est pas vide, le claim aud doit la contenir.
+func parseJWT(token string, secret []byte, audience string) (Caller, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Caller{}, errors.New("malformed JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return Caller{}, err
+	}
+	if header.Alg != "HS256" {
+		return Caller{}, errors.New("unsupported JWT algorithm " + header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Caller{}, errors.New("malformed JWT signature")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return Caller{}, errors.New("invalid JWT signature")
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return Caller{}, err
+	}
+	now := time.Now().Unix()
+	if claims.ExpiresAt == 0 || now >= claims.ExpiresAt {
+		return Caller{}, errors.New("JWT expired")
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore {
+		return Caller{}, errors.New("JWT not valid yet")
+	}
+	if claims.Subject == "" {
+		return Caller{}, errors.New("JWT without subject")
+	}
+	if audience != "" && !claims.hasAudience(audience) {
+		return Caller{}, errors.New("JWT not issued for this audience")
+	}
+
+	scopes := claims.Scopes
+	if claims.Scope != "" {
+		scopes = append(scopes, strings.Fields(claims.Scope)...)
+	}
+	return Caller{ID: claims.Subject, Scopes: scopes}, nil
+}
+
+// hasAudience indique si le claim aud, chaîne ou liste de chaînes, contient audience.
+func (c jwtClaims) hasAudience(audience string) bool {
+	var single string
+	if err := json.Unmarshal(c.Audience, &single); err == nil {
+		return single == audience
+	}
+	var list []string
+	if err := json.Unmarshal(c.Audience, &list); err != nil {
+		return false
+	}
+	for _, aud := range list {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errors.New("malformed JWT")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("malformed JWT")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Libellé des requêtes qui ne correspondent à aucune route
+const unmatchedRoute = "unmatched"
+
+// observe mesure chaque requête par méthode, modèle de route et statut.
+func (s *server) observe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		s.cfg.Metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+
+	if rec := serve(gateway, http.MethodGet, "/payment-status/00000000-0000-4000-8000-000000000001", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	serve(gateway, http.MethodGet, "/unknown", "", nil)
+
+	rec := serve(gateway, http.MethodGet, "/metrics", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`momo_gateway_requests_total{method="GET",route="/payment-status/:reference_id",status="200"} 1`,
+		`momo_gateway_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`momo_client_requests_total{code="",operation="get payment status",status="200"} 1`,
+		`momo_client_token_refreshes_total{product="collection",result="success"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in the metrics", want)
+		}
+	}
+}
+
+func TestFinalStatusMetric(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+
+	referenceID := createPayment(t, gateway)
+	for i := 0; i < 2; i++ {
+		if rec := serve(gateway, http.MethodPut, "/callbacks/requesttopay/"+referenceID, `{"status": "SUCCESSFUL"}`, nil); rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+	}
+
+	// Le callback renvoyé par MoMo n'est compté qu'une fois
+	rec := serve(gateway, http.MethodGet, "/metrics", "", nil)
+	want := `momo_payments_final_total{currency="EUR",product="collection",status="SUCCESSFUL"} 1`
+	if !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected %s in the metrics", want)
+	}
+}
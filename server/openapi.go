@@ -0,0 +1,490 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/enzoforreal/mtn-momo-api/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// Version de l'API décrite par la spécification OpenAPI
+const apiVersion = "1.0.0"
+
+type object = map[string]interface{}
+
+// Structure pour une opération décrite dans la spécification
+type operation struct {
+	method      string
+	path        string
+	summary     string
+	scope       string
+	params      []object
+	body        object
+	responses   map[int]object
+	problems    []int
+	contentType string
+}
+
+// specBuilder collecte les modèles référencés par les opérations.
+type specBuilder struct {
+	components map[string]reflect.Type
+}
+
+// operations décrit chaque route de la passerelle. TestOpenAPIMatchesRoutes échoue
+// si une route est ajoutée ou modifiée sans mettre cette liste à jour.
+func (b *specBuilder) operations() []operation {
+	referenceID := pathParam(httpapi.ReferenceIDParam, "Reference ID (UUID v4) of the resource")
+
+	return []operation{
+		{
+			method: http.MethodPost, path: "/create-api-user", scope: ScopeProvisioningAdmin,
+			summary: "Create a sandbox API user",
+			body: jsonBody(object{
+				"type": "object",
+				"properties": object{
+					"reference_id":  object{"type": "string", "format": "uuid", "description": "Generated when empty"},
+					"callback_host": object{"type": "string", "description": "Provider callback host registered for the user"},
+				},
+			}),
+			responses: map[int]object{http.StatusCreated: jsonResponse("API user created", messageSchema("reference_id"))},
+			problems:  []int{http.StatusBadRequest},
+		},
+		{
+			method: http.MethodPost, path: "/create-api-key", scope: ScopeProvisioningAdmin,
+			summary: "Create an API key for a sandbox API user",
+			body: jsonBody(object{
+				"type":       "object",
+				"required":   []string{"reference_id"},
+				"properties": object{"reference_id": object{"type": "string", "format": "uuid"}},
+			}),
+			responses: map[int]object{http.StatusCreated: jsonResponse("API key created", object{
+				"type":       "object",
+				"properties": object{"api_key": object{"type": "string"}},
+			})},
+			problems: []int{http.StatusBadRequest},
+		},
+		{
+			method: http.MethodGet, path: "/api-user/{reference_id}", scope: ScopeProvisioningAdmin,
+			summary: "Get a sandbox API user",
+			params:  []object{referenceID},
+			responses: map[int]object{http.StatusOK: jsonResponse("API user", object{
+				"type": "object",
+				"properties": object{
+					"reference_id":           object{"type": "string"},
+					"provider_callback_host": object{"type": "string"},
+					"target_environment":     object{"type": "string"},
+				},
+			})},
+			problems: []int{http.StatusNotFound},
+		},
+		{
+			method: http.MethodPost, path: "/get-auth-token", scope: ScopeProvisioningAdmin,
+			summary: "Refresh the collection token held by the gateway. The token itself is never returned.",
+			responses: map[int]object{http.StatusOK: jsonResponse("Token refreshed", object{
+				"type": "object",
+				"properties": object{
+					"message":    object{"type": "string"},
+					"expires_at": object{"type": "string", "format": "date-time"},
+				},
+			})},
+		},
+		{
+			method: http.MethodPost, path: "/request-to-pay", scope: ScopePaymentsCreate,
+			summary: "Request a payment from a consumer",
+			params: []object{
+				headerParam("X-Callback-Url", "Callback URL for this request, on the registered callback host"),
+				headerParam("Idempotency-Key", "Replays the original response when the same request is retried"),
+			},
+			body:      jsonBody(b.ref(momo.RequestToPay{})),
+			responses: map[int]object{http.StatusAccepted: jsonResponse("Payment request accepted by MoMo", messageSchema("reference_id"))},
+			problems:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+		},
+		{
+			method: http.MethodGet, path: "/payment-status/{reference_id}", scope: ScopePaymentsRead,
+			summary:   "Get the status of a payment request",
+			params:    []object{referenceID},
+			responses: map[int]object{http.StatusOK: jsonResponse("Payment status", b.ref(momo.RequestToPayResult{}))},
+			problems:  []int{http.StatusNotFound},
+		},
+		{
+			method: http.MethodPost, path: "/payments/{reference_id}/stream-ticket", scope: ScopePaymentsRead,
+			summary: "Issue a single-use ticket to open the status stream of a payment within a minute",
+			params:  []object{referenceID},
+			responses: map[int]object{http.StatusCreated: jsonResponse("Stream ticket", object{
+				"type": "object",
+				"properties": object{
+					"ticket":    object{"type": "string"},
+					"expiresAt": object{"type": "string", "format": "date-time"},
+				},
+			})},
+		},
+		{
+			method: http.MethodGet, path: "/payments/{reference_id}/events", scope: ScopePaymentsRead,
+			summary: "Stream the status of a payment as Server-Sent Events, until it is final",
+			params:  []object{referenceID, streamTicketParamSpec(), headerParam("Last-Event-ID", "Last status received; a final status ends the stream with 204")},
+			responses: map[int]object{
+				http.StatusOK:        {"description": "A \"status\" event with the current status, then one per change", "content": object{"text/event-stream": object{"schema": b.ref(httpapi.PaymentUpdate{})}}},
+				http.StatusNoContent: {"description": "The payment already reached a final status"},
+			},
+			problems: []int{http.StatusNotFound},
+		},
+		{
+			method: http.MethodGet, path: "/payments/{reference_id}/ws", scope: ScopePaymentsRead,
+			summary: "Stream the status of a payment over a WebSocket, one JSON message per update, until it is final",
+			params:  []object{referenceID, streamTicketParamSpec()},
+			responses: map[int]object{
+				http.StatusSwitchingProtocols: {"description": "WebSocket messages", "content": object{"application/json": object{"schema": b.ref(httpapi.PaymentUpdate{})}}},
+			},
+			problems: []int{http.StatusNotFound},
+		},
+		{
+			method: http.MethodGet, path: "/get-account-balance", scope: ScopeBalanceRead,
+			summary: "Get the collection account balance",
+			responses: map[int]object{http.StatusOK: jsonResponse("Account balance", object{
+				"type":       "object",
+				"properties": object{"balance": b.ref(momo.Balance{})},
+			})},
+		},
+		{
+			method: http.MethodGet, path: "/debug/momo", scope: ScopeProvisioningAdmin,
+			summary: "Diagnostics of the MoMo connection: environment, base URL, cached tokens, latency and errors of recent calls",
+			responses: map[int]object{http.StatusOK: jsonResponse("Diagnostics", object{
+				"type": "object",
+				"properties": object{
+					"environment": object{"type": "string"},
+					"baseUrl":     object{"type": "string"},
+					"tokens":      object{"type": "object", "additionalProperties": object{"type": "object"}},
+					"upstream":    object{"type": "array", "items": b.ref(momo.OperationStats{})},
+					"recentCalls": object{"type": "array", "items": b.ref(momo.UpstreamCall{})},
+				},
+			})},
+		},
+		{
+			method: http.MethodGet, path: "/webhooks/subscribers", scope: ScopeWebhooksAdmin,
+			summary: "List the webhook subscribers, without their secrets",
+			responses: map[int]object{http.StatusOK: jsonResponse("Subscribers", object{
+				"type":       "object",
+				"properties": object{"subscribers": object{"type": "array", "items": b.ref(webhook.Subscriber{})}},
+			})},
+		},
+		{
+			method: http.MethodPost, path: "/webhooks/subscribers", scope: ScopeWebhooksAdmin,
+			summary: "Register a webhook subscriber. The signing secret is only returned in this response.",
+			body: jsonBody(object{
+				"type":     "object",
+				"required": []string{"url", "eventTypes"},
+				"properties": object{
+					"url":        object{"type": "string", "format": "uri"},
+					"eventTypes": object{"type": "array", "items": object{"type": "string"}, "description": "Event types such as requesttopay.successful, requesttopay.* or *"},
+				},
+			}),
+			responses: map[int]object{http.StatusCreated: jsonResponse("Subscriber registered", b.ref(webhook.Subscriber{}))},
+			problems:  []int{http.StatusBadRequest},
+		},
+		{
+			method: http.MethodDelete, path: "/webhooks/subscribers/{id}", scope: ScopeWebhooksAdmin,
+			summary:   "Remove a webhook subscriber",
+			params:    []object{pathParam("id", "Subscriber ID")},
+			responses: map[int]object{http.StatusNoContent: {"description": "Subscriber removed"}},
+			problems:  []int{http.StatusNotFound},
+		},
+		{
+			method: http.MethodGet, path: "/webhooks/dead-letters", scope: ScopeWebhooksAdmin,
+			summary: "List the webhook deliveries abandoned after all retries",
+			responses: map[int]object{http.StatusOK: jsonResponse("Dead letters", object{
+				"type":       "object",
+				"properties": object{"deadLetters": object{"type": "array", "items": b.ref(webhook.DeadLetter{})}},
+			})},
+		},
+		{
+			method: http.MethodPost, path: "/webhooks/dead-letters/{id}/redeliver", scope: ScopeWebhooksAdmin,
+			summary:   "Remove a dead letter from the list and deliver its event again",
+			params:    []object{pathParam("id", "Dead letter ID")},
+			responses: map[int]object{http.StatusAccepted: jsonResponse("Redelivery started", messageSchema())},
+			problems:  []int{http.StatusNotFound},
+		},
+		b.callbackOperation(http.MethodPut),
+		b.callbackOperation(http.MethodPost),
+		{
+			method: http.MethodGet, path: "/healthz",
+			summary:   "Liveness check",
+			responses: map[int]object{http.StatusOK: jsonResponse("The gateway is running", object{"type": "object", "properties": object{"status": object{"type": "string"}}})},
+		},
+		{
+			method: http.MethodGet, path: "/readyz",
+			summary: "Readiness check: credentials loaded, MoMo token available, stores reachable",
+			responses: map[int]object{
+				http.StatusOK:                 jsonResponse("The gateway is ready", readinessSchema()),
+				http.StatusServiceUnavailable: jsonResponse("A check failed", readinessSchema()),
+			},
+		},
+		{
+			method: http.MethodGet, path: "/metrics",
+			summary:   "Gateway and MoMo client metrics",
+			responses: map[int]object{http.StatusOK: {"description": "Prometheus text exposition format", "content": object{"text/plain": object{}}}},
+		},
+		{
+			method: http.MethodGet, path: "/openapi.json",
+			summary:   "This OpenAPI document",
+			responses: map[int]object{http.StatusOK: jsonResponse("OpenAPI 3.1 document", object{"type": "object"})},
+		},
+		{
+			method: http.MethodGet, path: "/docs",
+			summary:   "API documentation page",
+			responses: map[int]object{http.StatusOK: {"description": "HTML page", "content": object{"text/html": object{}}}},
+		},
+	}
+}
+
+func (b *specBuilder) callbackOperation(method string) operation {
+	return operation{
+		method: method, path: "/callbacks/{path}",
+		summary: "Receive a MoMo notification, e.g. /callbacks/requesttopay/{referenceId}",
+		params:  []object{pathParam("path", "Operation type (requesttopay, transfer, deposit, refund, withdrawal), optionally followed by the reference ID")},
+		body: jsonBody(object{"oneOf": []interface{}{
+			b.ref(momo.RequestToPayResult{}),
+			b.ref(momo.TransferResult{}),
+		}}),
+		responses: map[int]object{http.StatusOK: {"description": "Notification acknowledged"}},
+		problems:  []int{http.StatusBadRequest, http.StatusNotFound},
+	}
+}
+
+// buildOpenAPI construit la spécification OpenAPI 3.1 de la passerelle.
+func buildOpenAPI() object {
+	b := &specBuilder{components: make(map[string]reflect.Type)}
+	paths := object{}
+	for _, op := range b.operations() {
+		item, ok := paths[op.path].(object)
+		if !ok {
+			item = object{}
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = op.spec()
+	}
+
+	schemas := object{}
+	schemas["Problem"] = b.structSchema(reflect.TypeOf(httpapi.Problem{}))
+	for name, t := range b.components {
+		schemas[name] = b.structSchema(t)
+	}
+
+	return object{
+		"openapi": "3.1.0",
+		"info": object{
+			"title":       "MTN MoMo API gateway",
+			"version":     apiVersion,
+			"description": "HTTP gateway in front of the MTN Mobile Money API. The gateway holds the MoMo credentials and tokens; callers authenticate with their own API key or JWT.",
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				"ApiKeyAuth": object{"type": "apiKey", "in": "header", "name": callerKeyHeader},
+				"BearerAuth": object{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+func (op operation) spec() object {
+	spec := object{"summary": op.summary}
+	if len(op.params) > 0 {
+		spec["parameters"] = op.params
+	}
+	if op.body != nil {
+		if op.contentType != "" {
+			spec["requestBody"] = object{"required": true, "content": object{op.contentType: object{"schema": op.body}}}
+		} else {
+			spec["requestBody"] = op.body
+		}
+	}
+
+	responses := object{}
+	for status, response := range op.responses {
+		responses[strconv.Itoa(status)] = response
+	}
+	problems := op.problems
+	if op.scope != "" {
+		spec["security"] = []object{{"ApiKeyAuth": []string{op.scope}}, {"BearerAuth": []string{op.scope}}}
+		spec["x-required-scope"] = op.scope
+		problems = append(problems, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable)
+	}
+	for _, status := range problems {
+		responses[strconv.Itoa(status)] = object{
+			"description": http.StatusText(status),
+			"content":     object{httpapi.ProblemContentType: object{"schema": object{"$ref": "#/components/schemas/Problem"}}},
+		}
+	}
+	spec["responses"] = responses
+	return spec
+}
+
+func streamTicketParamSpec() object {
+	return object{"name": streamTicketParam, "in": "query", "required": false, "description": "Single-use stream ticket, for clients that cannot send an Authorization header", "schema": object{"type": "string"}}
+}
+
+func readinessSchema() object {
+	return object{
+		"type": "object",
+		"properties": object{
+			"status": object{"type": "string", "enum": []string{"ready", "not ready"}},
+			"checks": object{"type": "object", "additionalProperties": object{"type": "string"}},
+		},
+	}
+}
+
+func pathParam(name, description string) object {
+	return object{"name": name, "in": "path", "required": true, "description": description, "schema": object{"type": "string"}}
+}
+
+func headerParam(name, description string) object {
+	return object{"name": name, "in": "header", "required": false, "description": description, "schema": object{"type": "string"}}
+}
+
+func jsonBody(schema object) object {
+	return object{"required": true, "content": object{"application/json": object{"schema": schema}}}
+}
+
+func jsonResponse(description string, schema object) object {
+	return object{"description": description, "content": object{"application/json": object{"schema": schema}}}
+}
+
+func messageSchema(fields ...string) object {
+	properties := object{"message": object{"type": "string"}}
+	for _, field := range fields {
+		properties[field] = object{"type": "string"}
+	}
+	return object{"type": "object", "properties": properties}
+}
+
+// ref enregistre le type de v comme composant et renvoie une référence vers lui.
+func (b *specBuilder) ref(v interface{}) object {
+	return b.typeSchema(reflect.TypeOf(v))
+}
+
+func (b *specBuilder) typeSchema(t reflect.Type) object {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return object{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": b.typeSchema(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": b.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = t
+			b.structSchema(t)
+		}
+		return object{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return object{}
+	}
+}
+
+// Champs sans omitempty que l'appelant peut omettre
+var optionalFields = map[string]bool{
+	"RequestToPay.payerMessage": true,
+	"RequestToPay.payeeNote":    true,
+	"Transfer.payerMessage":     true,
+	"Transfer.payeeNote":        true,
+}
+
+// Contraintes reprises de momo.RequestToPay.Validate
+var fieldConstraints = map[string]object{
+	"RequestToPay.amount":       {"pattern": `^[0-9]+(\.[0-9]{1,2})?$`, "description": "Positive amount with at most 2 decimals"},
+	"RequestToPay.currency":     {"pattern": "^[A-Z]{3}$", "description": "ISO 4217 currency code"},
+	"RequestToPay.externalId":   {"minLength": 1, "maxLength": momo.MaxExternalIDLen},
+	"RequestToPay.payerMessage": {"maxLength": momo.MaxMessageLength},
+	"RequestToPay.payeeNote":    {"maxLength": momo.MaxMessageLength},
+	"Payer.partyIdType":         {"enum": []string{momo.PartyIdTypeMSISDN, momo.PartyIdTypeEmail, momo.PartyIdTypePartyCode}},
+	"Payer.partyId":             {"description": "MSISDN with country code and without +, email address or party code"},
+}
+
+// structSchema décrit une structure à partir de ses tags json.
+func (b *specBuilder) structSchema(t reflect.Type) object {
+	properties := object{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := b.typeSchema(field.Type)
+		for key, value := range fieldConstraints[t.Name()+"."+name] {
+			schema[key] = value
+		}
+		properties[name] = schema
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr && !optionalFields[t.Name()+"."+name] {
+			required = append(required, name)
+		}
+	}
+
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// Page de documentation servie sur /docs
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>MTN MoMo API gateway</title>
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <redoc spec-url="openapi.json"></redoc>
+  <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+func (s *server) openAPIHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", s.openAPI)
+}
+
+func (s *server) docsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
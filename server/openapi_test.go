@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ginParam reconnaît les paramètres :name et *name des chemins gin.
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z_]+)`)
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+
+	routes := make(map[string]bool)
+	for _, route := range gateway.(*gin.Engine).Routes() {
+		routes[route.Method+" "+ginParam.ReplaceAllString(route.Path, "{$1}")] = true
+	}
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(mustMarshal(buildOpenAPI()), &spec); err != nil {
+		t.Fatal(err)
+	}
+	documented := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for method := range item {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	var missing, stale []string
+	for route := range routes {
+		if !documented[route] {
+			missing = append(missing, route)
+		}
+	}
+	for route := range documented {
+		if !routes[route] {
+			stale = append(stale, route)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	if len(missing) > 0 {
+		t.Errorf("routes missing from the OpenAPI spec: %v", missing)
+	}
+	if len(stale) > 0 {
+		t.Errorf("OpenAPI operations without a route: %v", stale)
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{CallerKeys: []CallerKey{{ID: "test", Hash: HashCallerKey("test-key")}}})
+
+	rec := serve(gateway, http.MethodGet, "/openapi.json", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var spec struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]struct {
+				Required []string `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI != "3.1.0" {
+		t.Fatalf("expected OpenAPI 3.1.0, got %q", spec.OpenAPI)
+	}
+	for _, name := range []string{"RequestToPay", "RequestToPayResult", "TransferResult", "Balance", "Payer", "Problem", "FieldError"} {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("expected schema %s", name)
+		}
+	}
+	if required := spec.Components.Schemas["RequestToPay"].Required; strings.Join(required, ",") != "amount,currency,externalId,payer" {
+		t.Errorf("unexpected required RequestToPay fields %v", required)
+	}
+
+	rec = serve(gateway, http.MethodGet, "/docs", "", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "openapi.json") {
+		t.Fatalf("expected the docs page, got %d", rec.Code)
+	}
+}
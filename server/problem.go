@@ -0,0 +1,56 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/gin-gonic/gin"
+)
+
+// Taille maximale du corps d'une requête lu par un middleware
+const maxRequestBodySize = 1 << 20
+
+// correlationID attribue un identifiant de corrélation à chaque requête, lu par
+// httpapi dans le contexte de la requête.
+func correlationID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = httpapi.WithCorrelationID(c.Writer, c.Request)
+		c.Next()
+	}
+}
+
+// problem écrit une réponse application/problem+json et interrompt la requête.
+func problem(c *gin.Context, p httpapi.Problem) {
+	httpapi.WriteProblem(c.Writer, c.Request, p)
+	c.Abort()
+}
+
+func badRequest(c *gin.Context, detail string) {
+	problem(c, httpapi.Problem{Status: http.StatusBadRequest, Detail: detail})
+}
+
+// fail traduit une erreur du client MoMo avec httpapi.WriteError et interrompt la requête.
+func fail(c *gin.Context, err error) {
+	httpapi.WriteError(c.Writer, c.Request, err)
+	c.Abort()
+}
+
+// readBody lit le corps de la requête, dans la limite de maxRequestBodySize, et le remet en
+// place pour le handler. En cas d'erreur, un problème 413 ou 400 est écrit et false renvoyé.
+func readBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBodySize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		problem(c, httpapi.Problem{Status: http.StatusRequestEntityTooLarge, Detail: "request body is too large"})
+		return nil, false
+	}
+	if err != nil {
+		badRequest(c, err.Error())
+		return nil, false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+)
+
+func TestProblemResponse(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/collection/token/" {
+			json.NewEncoder(w).Encode(momo.AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code": "PAYER_NOT_FOUND", "message": "Payer not found"}`))
+	}))
+	defer upstream.Close()
+
+	client := momo.NewClientFromConfig(momo.Config{
+		Environment: "sandbox",
+		BaseURL:     upstream.URL,
+		Collection:  momo.Credentials{SubscriptionKey: "test-subscription-key", ApiUserID: "test-api-user-id", ApiKey: "test-api-key"},
+	})
+	gateway := New(Config{AllowAnonymous: true}, client)
+
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+	rec := serve(gateway, http.MethodPost, "/request-to-pay", body, map[string]string{"X-Correlation-Id": "test-correlation-id"})
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("expected application/problem+json, got %q", ct)
+	}
+
+	var p httpapi.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != http.StatusNotFound || p.Code != momo.CodePayerNotFound || p.Detail != "Payer not found" {
+		t.Fatalf("unexpected problem %+v", p)
+	}
+	if p.CorrelationID != "test-correlation-id" || rec.Header().Get("X-Correlation-Id") != "test-correlation-id" {
+		t.Fatalf("expected the caller's correlation ID, got %q", p.CorrelationID)
+	}
+}
+
+func TestUnreachableUpstream(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+
+	client := momo.NewClientFromConfig(momo.Config{Environment: "sandbox", BaseURL: upstream.URL})
+	gateway := New(Config{AllowAnonymous: true}, client)
+
+	rec := serve(gateway, http.MethodGet, "/get-account-balance", "", nil)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Correlation-Id") == "" {
+		t.Fatal("expected a generated correlation ID")
+	}
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/gin-gonic/gin"
+)
+
+// Structure pour une limite de débit en seau à jetons : Burst jetons au plus,
+// renouvelés au rythme de Rate par seconde. Une limite nulle est désactivée.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute renvoie une limite de n requêtes par minute, avec une rafale de n.
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// Enabled indique si la limite s'applique.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// ParseLimit lit une limite de la forme "60/m" : 60 requêtes par minute, avec une rafale
+// de 60. Les unités acceptées sont s, m et h.
+func ParseLimit(value string) (Limit, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(value), "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected e.g. 60/m", value)
+	}
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("invalid rate limit unit in %q, expected s, m or h", value)
+	}
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}, nil
+}
+
+// Structure pour la configuration de la limitation de débit
+type RateLimits struct {
+	// PerCaller limite chaque appelant, séparément sur chaque route.
+	PerCaller Limit
+	// Routes remplace PerCaller pour certaines routes, par exemple "/request-to-pay".
+	Routes map[string]Limit
+	// PerPayer limite les demandes de paiement adressées à un même partyId, tous appelants confondus.
+	PerPayer Limit
+	// Store conserve les seaux à jetons. Par défaut, en mémoire ; un store partagé
+	// applique les limites à l'ensemble des réplicas.
+	Store LimiterStore
+}
+
+// LimiterStore consomme un jeton du seau identifié par key. Quand le seau est vide,
+// il renvoie false et le délai avant le prochain jeton.
+type LimiterStore interface {
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// Durée après laquelle un seau plein et inutilisé est oublié
+const idleBucketTTL = 10 * time.Minute
+
+// MemoryLimiterStore est un LimiterStore en mémoire, limité à un seul processus.
+type MemoryLimiterStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPurge time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var _ LimiterStore = (*MemoryLimiterStore)(nil)
+
+func NewMemoryLimiterStore() *MemoryLimiterStore {
+	return &MemoryLimiterStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryLimiterStore) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastPurge) > idleBucketTTL {
+		for k, b := range s.buckets {
+			if now.Sub(b.last) > idleBucketTTL {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastPurge = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		return false, wait, nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// rateLimitCaller limite chaque appelant sur chaque route.
+func (s *server) rateLimitCaller() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		limit, ok := s.cfg.RateLimits.Routes[route]
+		if !ok {
+			limit = s.cfg.RateLimits.PerCaller
+		}
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+		if s.allow(c, "caller|"+callerFrom(c).ID+"|"+route, limit, "caller") {
+			c.Next()
+		}
+	}
+}
+
+// rateLimitPayer limite les demandes de paiement adressées à un même payeur.
+func (s *server) rateLimitPayer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := s.cfg.RateLimits.PerPayer
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		body, ok := readBody(c)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Payer struct {
+				PartyId string `json:"partyId"`
+			} `json:"payer"`
+		}
+		// Un corps invalide est rejeté plus loin par la validation
+		if json.Unmarshal(body, &req) != nil || req.Payer.PartyId == "" {
+			c.Next()
+			return
+		}
+		if s.allow(c, "payer|"+req.Payer.PartyId, limit, "payer") {
+			c.Next()
+		}
+	}
+}
+
+// allow consomme un jeton, ou répond 429 avec Retry-After. Si le store est indisponible,
+// la requête est laissée passer.
+func (s *server) allow(c *gin.Context, key string, limit Limit, scope string) bool {
+	allowed, retryAfter, err := s.cfg.RateLimits.Store.Allow(c.Request.Context(), key, limit)
+	if err != nil {
+		log.Printf("Error checking rate limit for %s: %v", key, err)
+		return true
+	}
+	if allowed {
+		return true
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	problem(c, httpapi.Problem{
+		Status: http.StatusTooManyRequests,
+		Detail: fmt.Sprintf("Rate limit exceeded for this %s, retry in %d seconds", scope, seconds),
+	})
+	return false
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/enzoforreal/mtn-momo-api/momopb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMemoryLimiterStoreRefills(t *testing.T) {
+	store := NewMemoryLimiterStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := store.Allow(context.Background(), "key", limit); !allowed {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+	allowed, retryAfter, _ := store.Allow(context.Background(), "key", limit)
+	if allowed || retryAfter != time.Second {
+		t.Fatalf("expected a 1s wait once the burst is spent, got allowed=%v retryAfter=%v", allowed, retryAfter)
+	}
+
+	now = now.Add(time.Second)
+	if allowed, _, _ := store.Allow(context.Background(), "key", limit); !allowed {
+		t.Fatal("expected a token after one second")
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("120/m")
+	if err != nil || limit.Burst != 120 || limit.Rate != 2 {
+		t.Fatalf("unexpected limit %+v, err %v", limit, err)
+	}
+	for _, value := range []string{"", "10", "0/m", "10/d"} {
+		if _, err := ParseLimit(value); err == nil {
+			t.Fatalf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestRateLimitPerPayer(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{RateLimits: RateLimits{PerPayer: PerMinute(1)}})
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, nil); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := serve(gateway, http.MethodPost, "/request-to-pay", body, nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected Retry-After: 60, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	other := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123454"}}`
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", other, nil); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected another payer to be allowed, got %d", rec.Code)
+	}
+}
+
+func TestPayerLimitBoundsBody(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{RateLimits: RateLimits{PerPayer: PerMinute(10)}})
+
+	// Sans Idempotency-Key, le corps est lu par rateLimitPayer
+	body := `{"externalId": "` + strings.Repeat("x", maxRequestBodySize) + `"}`
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, nil); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitPerCallerAndRoute(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{RateLimits: RateLimits{
+		PerCaller: PerMinute(1),
+		Routes:    map[string]Limit{"/get-account-balance": PerMinute(2)},
+	}})
+
+	for i := 0; i < 2; i++ {
+		if rec := serve(gateway, http.MethodGet, "/get-account-balance", "", nil); rec.Code != http.StatusOK {
+			t.Fatalf("expected balance request %d to succeed, got %d", i+1, rec.Code)
+		}
+	}
+	if rec := serve(gateway, http.MethodGet, "/get-account-balance", "", nil); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %d", rec.Code)
+	}
+
+	// Chaque route a son propre seau
+	if rec := serve(gateway, http.MethodGet, "/payment-status/00000000-0000-4000-8000-000000000001", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected the status route to be allowed, got %d", rec.Code)
+	}
+	if rec := serve(gateway, http.MethodGet, "/payment-status/00000000-0000-4000-8000-000000000002", "", nil); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429 on the second status request, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitedRequestDoesNotKeepIdempotencyKey(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{RateLimits: RateLimits{PerPayer: Limit{Rate: 20, Burst: 1}}})
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, map[string]string{"Idempotency-Key": "order-1"}); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	headers := map[string]string{"Idempotency-Key": "order-2"}
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, headers); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %d", rec.Code)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	rec := serve(gateway, http.MethodPost, "/request-to-pay", body, headers)
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("expected the retry to be accepted once the bucket refilled, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPayerLimitSharedAcrossTransports(t *testing.T) {
+	cfg := Config{RateLimits: RateLimits{PerPayer: PerMinute(1), Store: NewMemoryLimiterStore()}}
+	gateway, _ := newTestGateway(t, cfg)
+	client := newTestGRPC(t, cfg)
+
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+	if rec := serve(gateway, http.MethodPost, "/request-to-pay", body, nil); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Le même payeur, par gRPC, a déjà consommé son jeton
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := client.RequestToPay(ctx, &momopb.RequestToPayRequest{
+		Amount:     "100",
+		Currency:   "EUR",
+		ExternalId: "123456",
+		Payer:      &momopb.Party{PartyIdType: momo.PartyIdTypeMSISDN, PartyId: "46733123453"},
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted after the REST request, got %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+// Package server expose les opérations MoMo sous forme de passerelle HTTP,
+// montable dans un autre service ou lancée seule avec ListenAndServe.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/adapters/ginadapter"
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/metrics"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/enzoforreal/mtn-momo-api/webhook"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Valeurs par défaut de la configuration
+const (
+	defaultAddr            = ":8080"
+	defaultGRPCAddr        = ":9090"
+	defaultShutdownTimeout = 15 * time.Second
+)
+
+// Structure pour la configuration de la passerelle
+type Config struct {
+	// Addr est l'adresse d'écoute utilisée par ListenAndServe.
+	Addr string
+	// GRPCAddr est l'adresse d'écoute utilisée par ListenAndServeGRPC.
+	GRPCAddr string
+	// ShutdownTimeout borne l'attente des requêtes en cours lors de l'arrêt.
+	ShutdownTimeout time.Duration
+	// CallerKeys sont les clés hachées acceptées dans l'en-tête X-Api-Key, avec leurs portées.
+	CallerKeys []CallerKey
+	// JWTSecret vérifie les JWT HS256 présentés dans l'en-tête Authorization.
+	// Sans clé ni secret, toutes les requêtes sont refusées, sauf avec AllowAnonymous.
+	JWTSecret []byte
+	// JWTAudience, si elle est définie, doit figurer dans le claim aud des JWT.
+	JWTAudience string
+	// AllowAnonymous traite les requêtes comme un appelant anonyme disposant de toutes les
+	// portées quand aucune clé ni secret JWT n'est configuré. Réservé au développement.
+	AllowAnonymous bool
+	// Idempotency conserve les réponses de /request-to-pay par Idempotency-Key. Par défaut, en mémoire.
+	Idempotency IdempotencyStore
+	// IdempotencyRetention est la durée pendant laquelle une clé d'idempotence est conservée.
+	IdempotencyRetention time.Duration
+	// StreamTickets conserve les tickets des flux de statut. Par défaut, en mémoire.
+	StreamTickets StreamTicketStore
+	// StreamTicketTTL est la durée de validité d'un ticket de flux. Par défaut, une minute.
+	StreamTicketTTL time.Duration
+	// RateLimits limite le débit par appelant, par route et par payeur.
+	RateLimits RateLimits
+	// StatusPollInterval est l'intervalle entre deux interrogations de MoMo pour un paiement
+	// suivi par un flux de statut. Par défaut, httpapi.DefaultStatusPollInterval.
+	StatusPollInterval time.Duration
+	// StatusHub diffuse les statuts aux flux de la passerelle et à WatchPayment. Partagé entre
+	// New et NewGRPC, il transmet au service gRPC les callbacks reçus par la passerelle.
+	// Par défaut, chacun crée le sien, interrogé toutes les StatusPollInterval.
+	StatusHub *httpapi.StatusHub
+	// Metrics mesure les routes de la passerelle et les appels du client, exposés sur /metrics.
+	// Par défaut, un registre dédié ; il est affecté à client.Metrics si ce dernier est vide.
+	Metrics *metrics.Collector
+	// TracerProvider crée les spans des requêtes. Par défaut, le fournisseur global d'OpenTelemetry ;
+	// il est affecté à client.TracerProvider si ce dernier est vide.
+	TracerProvider trace.TracerProvider
+	// OnCallback reçoit les notifications MoMo reçues sur /callbacks/. Par défaut, elles sont journalisées.
+	OnCallback momo.CallbackFunc
+	// Relay relaie aux abonnés enregistrés sur /webhooks/subscribers les notifications reçues
+	// sur /callbacks/ et les changements de statut observés en interrogeant MoMo pour les flux
+	// de statut, une seule fois par référence et par statut. Par défaut, un relais dédié ;
+	// ListenAndServe arrête celui fourni ici.
+	Relay *webhook.Relay
+}
+
+// ConfigFromEnv lit la configuration depuis SERVER_ADDR, GRPC_ADDR, SHUTDOWN_TIMEOUT,
+// GATEWAY_CALLER_KEYS_FILE (fichier JSON de CallerKey), GATEWAY_JWT_SECRET,
+// GATEWAY_JWT_AUDIENCE, GATEWAY_ALLOW_ANONYMOUS, IDEMPOTENCY_RETENTION,
+// STATUS_POLL_INTERVAL, RATE_LIMIT_CALLER et RATE_LIMIT_PAYER (par exemple "60/m").
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Addr:        os.Getenv("SERVER_ADDR"),
+		GRPCAddr:    os.Getenv("GRPC_ADDR"),
+		JWTSecret:   []byte(os.Getenv("GATEWAY_JWT_SECRET")),
+		JWTAudience: os.Getenv("GATEWAY_JWT_AUDIENCE"),
+	}
+	if value := os.Getenv("GATEWAY_ALLOW_ANONYMOUS"); value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			return cfg, fmt.Errorf("GATEWAY_ALLOW_ANONYMOUS: %w", err)
+		}
+		cfg.AllowAnonymous = allow
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT")); err == nil {
+		cfg.ShutdownTimeout = timeout
+	}
+	if retention, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_RETENTION")); err == nil {
+		cfg.IdempotencyRetention = retention
+	}
+	if interval, err := time.ParseDuration(os.Getenv("STATUS_POLL_INTERVAL")); err == nil {
+		cfg.StatusPollInterval = interval
+	}
+	for env, limit := range map[string]*Limit{
+		"RATE_LIMIT_CALLER": &cfg.RateLimits.PerCaller,
+		"RATE_LIMIT_PAYER":  &cfg.RateLimits.PerPayer,
+	} {
+		if value := os.Getenv(env); value != "" {
+			parsed, err := ParseLimit(value)
+			if err != nil {
+				return cfg, fmt.Errorf("%s: %w", env, err)
+			}
+			*limit = parsed
+		}
+	}
+	if path := os.Getenv("GATEWAY_CALLER_KEYS_FILE"); path != "" {
+		keys, err := LoadCallerKeys(path)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.CallerKeys = keys
+	}
+	return cfg, nil
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Addr == "" {
+		cfg.Addr = defaultAddr
+	}
+	if cfg.GRPCAddr == "" {
+		cfg.GRPCAddr = defaultGRPCAddr
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if cfg.Idempotency == nil {
+		cfg.Idempotency = NewMemoryIdempotencyStore()
+	}
+	if cfg.IdempotencyRetention <= 0 {
+		cfg.IdempotencyRetention = defaultIdempotencyRetention
+	}
+	if cfg.StreamTickets == nil {
+		cfg.StreamTickets = NewMemoryStreamTicketStore()
+	}
+	if cfg.StreamTicketTTL <= 0 {
+		cfg.StreamTicketTTL = defaultStreamTicketTTL
+	}
+	if cfg.RateLimits.Store == nil {
+		cfg.RateLimits.Store = NewMemoryLimiterStore()
+	}
+	if cfg.StatusPollInterval <= 0 {
+		cfg.StatusPollInterval = httpapi.DefaultStatusPollInterval
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = metrics.New()
+	}
+	if cfg.OnCallback == nil {
+		cfg.OnCallback = httpapi.LogCallback
+	}
+	if cfg.Relay == nil {
+		cfg.Relay = webhook.NewRelay()
+	}
+	return cfg
+}
+
+// statusHub renvoie cfg.StatusHub, ou un nouveau StatusHub pour client.
+func (cfg Config) statusHub(client *momo.Client) *httpapi.StatusHub {
+	if cfg.StatusHub != nil {
+		return cfg.StatusHub
+	}
+	hub := httpapi.NewStatusHub(client)
+	hub.PollInterval = cfg.StatusPollInterval
+	return hub
+}
+
+type server struct {
+	cfg     Config
+	client  *momo.Client
+	api     *httpapi.Handlers
+	router  *gin.Engine
+	openAPI []byte
+}
+
+// New construit la passerelle autour d'un client MoMo déjà configuré.
+func New(cfg Config, client *momo.Client) http.Handler {
+	cfg = cfg.withDefaults()
+	if client.Metrics == nil {
+		client.Metrics = cfg.Metrics
+	}
+	if client.TracerProvider == nil {
+		client.TracerProvider = cfg.TracerProvider
+	}
+	statuses := newStatusRelay(cfg.Relay)
+	s := &server{
+		cfg:     cfg,
+		client:  client,
+		api:     httpapi.New(client, statuses.callbacks(cfg.OnCallback)),
+		router:  gin.New(),
+		openAPI: mustMarshal(buildOpenAPI()),
+	}
+	hub := cfg.statusHub(client)
+	hub.OnChange(statuses.statusChanged)
+	s.api.UseHub(hub)
+	s.routes()
+	return s.router
+}
+
+func (s *server) routes() {
+	s.router.Use(correlationID(), s.trace(), s.observe(), gin.Logger(), gin.Recovery())
+
+	s.router.GET("/openapi.json", s.openAPIHandler)
+	s.router.GET("/docs", s.docsHandler)
+	s.router.GET("/healthz", s.healthzHandler)
+	s.router.GET("/readyz", s.readyzHandler)
+	s.router.GET("/metrics", gin.WrapH(s.cfg.Metrics.Handler()))
+
+	// Les callbacks viennent de MoMo et ne portent pas de clé d'appelant
+	auth := s.authenticate()
+	api := s.router.Group("/", auth, s.rateLimitCaller())
+
+	admin := requireScope(ScopeProvisioningAdmin)
+	api.POST("/create-api-user", admin, s.createAPIUserHandler)
+	api.POST("/create-api-key", admin, s.createAPIKeyHandler)
+	api.GET("/api-user/:reference_id", admin, s.getAPIUserHandler)
+	api.POST("/get-auth-token", admin, s.getAuthTokenHandler)
+	api.GET("/debug/momo", admin, s.debugHandler)
+
+	api.POST("/request-to-pay", requireScope(ScopePaymentsCreate), s.idempotent(), s.rateLimitPayer(), ginadapter.Wrap(s.api.RequestToPay))
+	api.GET("/payment-status/:reference_id", requireScope(ScopePaymentsRead), ginadapter.Wrap(s.api.PaymentStatus))
+	api.GET("/get-account-balance", requireScope(ScopeBalanceRead), ginadapter.Wrap(s.api.Balance))
+
+	webhooks := api.Group("/webhooks", requireScope(ScopeWebhooksAdmin))
+	webhooks.GET("/subscribers", s.listSubscribersHandler)
+	webhooks.POST("/subscribers", s.subscribeHandler)
+	webhooks.DELETE("/subscribers/:id", s.unsubscribeHandler)
+	webhooks.GET("/dead-letters", s.listDeadLettersHandler)
+	webhooks.POST("/dead-letters/:id/redeliver", s.redeliverHandler)
+
+	// EventSource et les WebSockets du navigateur ne peuvent pas envoyer d'en-têtes :
+	// les flux acceptent aussi un ticket à usage unique dans le paramètre ticket
+	api.POST("/payments/:reference_id/stream-ticket", requireScope(ScopePaymentsRead), s.streamTicketHandler)
+	streams := s.router.Group("/payments/:reference_id", s.redeemStreamTicket(), auth, s.rateLimitCaller(), requireScope(ScopePaymentsRead))
+	streams.GET("/events", ginadapter.Wrap(s.api.PaymentEvents))
+	streams.GET("/ws", ginadapter.Wrap(s.api.PaymentSocket))
+
+	callbacks := ginadapter.Wrap(s.api.Callbacks().ServeHTTP)
+	s.router.PUT("/callbacks/*path", callbacks)
+	s.router.POST("/callbacks/*path", callbacks)
+}
+
+// ListenAndServe sert handler sur cfg.Addr jusqu'à l'annulation de ctx ou la réception
+// de SIGINT/SIGTERM, puis attend les requêtes en cours et les livraisons de cfg.Relay
+// pendant cfg.ShutdownTimeout.
+func ListenAndServe(ctx context.Context, cfg Config, handler http.Handler) error {
+	relay := cfg.Relay
+	cfg = cfg.withDefaults()
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("Gateway listening on %s", cfg.Addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down gateway...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	if relay != nil {
+		if err := relay.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Webhook deliveries abandoned at shutdown: %v", err)
+		}
+	}
+	log.Println("Gateway stopped")
+	return nil
+}
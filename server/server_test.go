@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/internal/momotest"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestGateway construit une passerelle de test. Sans clé ni secret JWT dans cfg,
+// l'accès est anonyme.
+func newTestGateway(t *testing.T, cfg Config) (http.Handler, *momo.Client) {
+	cfg.AllowAnonymous = true
+	client := momotest.NewClient(t)
+	client.Pending = momo.NewMemoryPendingStore()
+	return New(cfg, client), client
+}
+
+// createPayment envoie une demande de paiement à la passerelle et renvoie sa référence.
+func createPayment(t *testing.T, gateway http.Handler) string {
+	t.Helper()
+	rec := serve(gateway, http.MethodPost, "/request-to-pay", momotest.PaymentBody, nil)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ReferenceID string `json:"reference_id"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	return created.ReferenceID
+}
+
+func TestRequestToPayAndStatus(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+
+	body := `{"amount": "100", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": "46733123453"}}`
+	req := httptest.NewRequest(http.MethodPost, "/request-to-pay", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ReferenceID string `json:"reference_id"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if created.ReferenceID == "" {
+		t.Fatal("expected a reference ID")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/payment-status/"+created.ReferenceID, nil)
+	rec = httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var status momo.RequestToPayResult
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	if status.Status != momo.StatusSuccessful || status.ReferenceId != created.ReferenceID {
+		t.Fatalf("unexpected payment status %+v", status)
+	}
+}
+
+func TestRequestToPayValidation(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+
+	body := `{"amount": "ten", "currency": "EUR", "externalId": "123456", "payer": {"partyIdType": "MSISDN", "partyId": ""}}`
+	rec := serve(gateway, http.MethodPost, "/request-to-pay", body, nil)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Fields []momo.FieldError `json:"fields"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if len(resp.Fields) != 2 || resp.Fields[0].Field != "amount" || resp.Fields[1].Field != "payer.partyId" {
+		t.Fatalf("unexpected field errors %+v", resp.Fields)
+	}
+}
+
+func TestAuthTokenIsNotReturned(t *testing.T) {
+	gateway, _ := newTestGateway(t, Config{})
+
+	req := httptest.NewRequest(http.MethodPost, "/get-auth-token", nil)
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "test-token") {
+		t.Fatalf("MoMo token leaked to the caller: %s", rec.Body.String())
+	}
+}
+
+func TestCallbacksAreForwarded(t *testing.T) {
+	var received momo.CallbackEvent
+	gateway, _ := newTestGateway(t, Config{
+		OnCallback: func(ctx context.Context, event momo.CallbackEvent) error {
+			received = event
+			return nil
+		},
+	})
+
+	referenceID := createPayment(t, gateway)
+	req := httptest.NewRequest(http.MethodPut, "/callbacks/requesttopay/"+referenceID, strings.NewReader(`{"status": "SUCCESSFUL"}`))
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if received == nil || received.Meta().ReferenceID != referenceID {
+		t.Fatalf("unexpected callback %+v", received)
+	}
+}
+
+func TestForgedCallbacksAreNotForwarded(t *testing.T) {
+	var received []momo.CallbackEvent
+	gateway, _ := newTestGateway(t, Config{
+		OnCallback: func(ctx context.Context, event momo.CallbackEvent) error {
+			received = append(received, event)
+			return nil
+		},
+	})
+
+	// Référence inconnue : le callback est acquitté mais ignoré
+	if rec := serve(gateway, http.MethodPut, "/callbacks/requesttopay/forged-reference-id", `{"status": "SUCCESSFUL"}`, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	// Le faux MoMo déclare le paiement SUCCESSFUL : un callback FAILED n'est pas confirmé
+	referenceID := createPayment(t, gateway)
+	serve(gateway, http.MethodPut, "/callbacks/requesttopay/"+referenceID, `{"status": "FAILED"}`, nil)
+
+	if len(received) != 0 {
+		t.Fatalf("expected forged callbacks to be dropped, got %+v", received)
+	}
+}
+
+func TestListenAndServeStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- ListenAndServe(ctx, Config{Addr: "127.0.0.1:0"}, http.NotFoundHandler())
+	}()
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("expected a clean shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/gin-gonic/gin"
+)
+
+// Paramètre de requête portant le ticket des flux de statut
+const streamTicketParam = "ticket"
+
+// Durée de validité par défaut d'un ticket de flux
+const defaultStreamTicketTTL = time.Minute
+
+// Structure pour un ticket de flux. Il n'ouvre qu'une seule connexion au flux d'un paiement.
+type StreamTicket struct {
+	Caller      Caller    `json:"caller"`
+	ReferenceID string    `json:"referenceId"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// StreamTicketStore conserve les tickets de flux en attente d'utilisation.
+//
+// Take renvoie et supprime le ticket de façon atomique, pour qu'il ne serve qu'une fois.
+type StreamTicketStore interface {
+	Put(ctx context.Context, ticket string, entry StreamTicket) error
+	Take(ctx context.Context, ticket string) (StreamTicket, bool, error)
+}
+
+// MemoryStreamTicketStore est un StreamTicketStore en mémoire, limité à un seul processus.
+type MemoryStreamTicketStore struct {
+	mu      sync.Mutex
+	tickets map[string]StreamTicket
+}
+
+var _ StreamTicketStore = (*MemoryStreamTicketStore)(nil)
+
+func NewMemoryStreamTicketStore() *MemoryStreamTicketStore {
+	return &MemoryStreamTicketStore{tickets: make(map[string]StreamTicket)}
+}
+
+func (s *MemoryStreamTicketStore) Put(ctx context.Context, ticket string, entry StreamTicket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, existing := range s.tickets {
+		if now.After(existing.ExpiresAt) {
+			delete(s.tickets, key)
+		}
+	}
+	s.tickets[ticket] = entry
+	return nil
+}
+
+func (s *MemoryStreamTicketStore) Take(ctx context.Context, ticket string) (StreamTicket, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.tickets[ticket]
+	delete(s.tickets, ticket)
+	return entry, ok, nil
+}
+
+// streamTicketHandler délivre à l'appelant authentifié un ticket pour ouvrir une fois le
+// flux de statut d'un paiement, avant StreamTicketTTL. EventSource et les WebSockets du
+// navigateur ne peuvent pas envoyer d'en-têtes : le ticket passe dans l'URL à la place
+// des identifiants de l'appelant, qui pourraient y être journalisés.
+func (s *server) streamTicketHandler(c *gin.Context) {
+	referenceID := c.Param(httpapi.ReferenceIDParam)
+	if err := momo.ValidateReferenceID(referenceID); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		fail(c, err)
+		return
+	}
+	ticket := hex.EncodeToString(secret)
+	entry := StreamTicket{
+		Caller:      callerFrom(c),
+		ReferenceID: referenceID,
+		ExpiresAt:   time.Now().Add(s.cfg.StreamTicketTTL),
+	}
+	if err := s.cfg.StreamTickets.Put(c.Request.Context(), ticket, entry); err != nil {
+		fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"ticket": ticket, "expiresAt": entry.ExpiresAt})
+}
+
+// redeemStreamTicket authentifie la requête par le ticket du paramètre ticket, s'il est
+// présent. Le ticket est consommé même s'il est refusé ; sans ticket, authenticate
+// identifie l'appelant par ses en-têtes.
+func (s *server) redeemStreamTicket() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket := c.Query(streamTicketParam)
+		if ticket == "" {
+			c.Next()
+			return
+		}
+
+		entry, ok, err := s.cfg.StreamTickets.Take(c.Request.Context(), ticket)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		if !ok || time.Now().After(entry.ExpiresAt) || entry.ReferenceID != c.Param(httpapi.ReferenceIDParam) {
+			problem(c, httpapi.Problem{Status: http.StatusUnauthorized, Detail: "invalid, expired or already used stream ticket"})
+			return
+		}
+		setCaller(c, entry.Caller)
+		c.Next()
+	}
+}
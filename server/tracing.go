@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Nom de l'instrumentation OpenTelemetry de la passerelle
+const tracerName = "github.com/enzoforreal/mtn-momo-api/server"
+
+// trace démarre un span par requête, enfant du contexte de trace reçu dans les en-têtes.
+// Les spans du client MoMo et des callbacks en deviennent les enfants.
+func (s *server) trace() gin.HandlerFunc {
+	provider := s.cfg.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	tracer := provider.Tracer(tracerName)
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", c.Request.URL.Path),
+			attribute.String("momo.correlation_id", httpapi.CorrelationID(ctx)),
+		))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if caller := callerFrom(c).ID; caller != "" {
+			span.SetAttributes(attribute.String("momo.caller_id", caller))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/enzoforreal/mtn-momo-api/internal/momotest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestGatewaySpans(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	exporter := tracetest.NewInMemoryExporter()
+	gateway, _ := newTestGateway(t, Config{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))})
+
+	rec := serve(gateway, http.MethodPost, "/request-to-pay", momotest.PaymentBody, map[string]string{
+		"Traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", rec.Code)
+	}
+	var created struct {
+		ReferenceID string `json:"reference_id"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	serve(gateway, http.MethodPut, "/callbacks/requesttopay/"+created.ReferenceID, `{"status": "SUCCESSFUL"}`, nil)
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+
+	route, ok := byName["POST /request-to-pay"]
+	if !ok {
+		t.Fatalf("expected a span for the gateway route, got %d spans", len(spans))
+	}
+	if route.Parent.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatal("expected the gateway span to continue the incoming trace")
+	}
+	if method := byName["momo.RequestToPay"]; method.Parent.SpanID() != route.SpanContext.SpanID() {
+		t.Fatal("expected the client span to be a child of the gateway span")
+	}
+
+	callbackRoute := byName["PUT /callbacks/*path"]
+	callback, ok := byName["momo.callback"]
+	if !ok || callback.Parent.SpanID() != callbackRoute.SpanContext.SpanID() {
+		t.Fatal("expected the callback span to be a child of the callback route span")
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/httpapi"
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/enzoforreal/mtn-momo-api/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// Durée pendant laquelle un statut relayé n'est plus relayé pour la même référence
+const relayedStatusTTL = 24 * time.Hour
+
+// statusRelay transmet au relais les statuts reçus par callback et ceux observés par
+// l'interrogation du StatusHub, une seule fois par référence et par statut : un paiement
+// résolu sans callback produit aussi son événement, sans doublon quand le callback arrive.
+type statusRelay struct {
+	relay *webhook.Relay
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func newStatusRelay(relay *webhook.Relay) *statusRelay {
+	return &statusRelay{relay: relay, sent: make(map[string]time.Time)}
+}
+
+// callbacks transmet chaque notification à onCallback, puis au relais une fois acceptée.
+func (r *statusRelay) callbacks(onCallback momo.CallbackFunc) momo.CallbackFunc {
+	return func(ctx context.Context, event momo.CallbackEvent) error {
+		if err := onCallback(ctx, event); err != nil {
+			return err
+		}
+		meta := event.Meta()
+		if !r.claim(meta.ReferenceID, event.Status()) {
+			return nil
+		}
+		return r.relay.PublishCallback(ctx, event)
+	}
+}
+
+// statusChanged relaie un changement de statut observé par interrogation. Ceux des callbacks
+// sont déjà passés par callbacks.
+func (r *statusRelay) statusChanged(update httpapi.PaymentUpdate) {
+	if update.Source != httpapi.SourcePoll || !r.claim(update.ReferenceID, update.Status) {
+		return
+	}
+	r.relay.PublishStatus(momo.CallbackRequestToPay, update.ReferenceID, update.Status, update.Result)
+}
+
+// claim marque le statut d'une référence comme relayé et renvoie false s'il l'était déjà.
+func (r *statusRelay) claim(referenceID, status string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for key, expiresAt := range r.sent {
+		if now.After(expiresAt) {
+			delete(r.sent, key)
+		}
+	}
+	key := referenceID + "|" + status
+	if _, ok := r.sent[key]; ok {
+		return false
+	}
+	r.sent[key] = now.Add(relayedStatusTTL)
+	return true
+}
+
+func (s *server) listSubscribersHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"subscribers": s.cfg.Relay.Subscribers()})
+}
+
+// subscribeHandler enregistre un abonné. Le secret de signature n'est renvoyé que dans
+// cette réponse.
+func (s *server) subscribeHandler(c *gin.Context) {
+	var req struct {
+		URL        string   `json:"url" binding:"required"`
+		EventTypes []string `json:"eventTypes" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	subscriber, err := s.cfg.Relay.Subscribe(webhook.Subscriber{URL: req.URL, EventTypes: req.EventTypes})
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	log.Printf("Webhook subscriber %s registered for %s", subscriber.ID, subscriber.URL)
+	c.JSON(http.StatusCreated, subscriber)
+}
+
+func (s *server) unsubscribeHandler(c *gin.Context) {
+	if err := s.cfg.Relay.Unsubscribe(c.Param("id")); err != nil {
+		webhookFail(c, err)
+		return
+	}
+
+	log.Printf("Webhook subscriber %s removed", c.Param("id"))
+	c.Status(http.StatusNoContent)
+}
+
+func (s *server) listDeadLettersHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"deadLetters": s.cfg.Relay.DeadLetters()})
+}
+
+func (s *server) redeliverHandler(c *gin.Context) {
+	if err := s.cfg.Relay.Redeliver(c.Param("id")); err != nil {
+		webhookFail(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Redelivery started"})
+}
+
+// webhookFail traduit une erreur du relais : référence inconnue en 404.
+func webhookFail(c *gin.Context, err error) {
+	if errors.Is(err, webhook.ErrUnknownSubscriber) || errors.Is(err, webhook.ErrUnknownDeadLetter) {
+		problem(c, httpapi.Problem{Status: http.StatusNotFound, Detail: err.Error()})
+		return
+	}
+	fail(c, err)
+}
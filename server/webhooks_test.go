@@ -0,0 +1,124 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/enzoforreal/mtn-momo-api/webhook"
+)
+
+func TestWebhookSubscribersReceiveCallbacks(t *testing.T) {
+	delivered := make(chan webhook.Event, 1)
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhook.Event
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &event)
+		delivered <- event
+	}))
+	defer subscriber.Close()
+
+	relay := webhook.NewRelay()
+	gateway, _ := newTestGateway(t, Config{Relay: relay})
+
+	rec := serve(gateway, http.MethodPost, "/webhooks/subscribers", `{"url": "`+subscriber.URL+`", "eventTypes": ["requesttopay.*"]}`, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created webhook.Subscriber
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if created.ID == "" || created.Secret == "" {
+		t.Fatalf("expected an ID and a secret, got %+v", created)
+	}
+
+	rec = serve(gateway, http.MethodGet, "/webhooks/subscribers", "", nil)
+	var listed struct {
+		Subscribers []webhook.Subscriber `json:"subscribers"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &listed)
+	if len(listed.Subscribers) != 1 || listed.Subscribers[0].Secret != "" {
+		t.Fatalf("expected one subscriber without its secret, got %+v", listed.Subscribers)
+	}
+
+	referenceID := createPayment(t, gateway)
+	rec = serve(gateway, http.MethodPut, "/callbacks/requesttopay/"+referenceID, `{"status": "SUCCESSFUL"}`, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	relay.Close()
+	select {
+	case event := <-delivered:
+		if event.Type != "requesttopay.successful" || event.Data.ReferenceID != referenceID {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("expected the callback to be relayed")
+	}
+
+	if rec := serve(gateway, http.MethodDelete, "/webhooks/subscribers/"+created.ID, "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", rec.Code)
+	}
+	if rec := serve(gateway, http.MethodDelete, "/webhooks/subscribers/"+created.ID, "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestWebhookSubscribersReceivePolledStatus(t *testing.T) {
+	const referenceID = "00000000-0000-4000-8000-000000000001"
+	var polls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/collection/token/" {
+			json.NewEncoder(w).Encode(momo.AuthToken{AccessToken: "test-token", ExpiresIn: 3600})
+			return
+		}
+		// Le paiement est PENDING à l'ouverture du flux, puis SUCCESSFUL, sans callback
+		status := momo.StatusPending
+		if polls.Add(1) > 1 {
+			status = momo.StatusSuccessful
+		}
+		json.NewEncoder(w).Encode(momo.RequestToPayResult{ReferenceId: referenceID, Status: status})
+	}))
+	defer upstream.Close()
+
+	delivered := make(chan webhook.Event, 2)
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhook.Event
+		json.NewDecoder(r.Body).Decode(&event)
+		delivered <- event
+	}))
+	defer subscriber.Close()
+
+	relay := webhook.NewRelay()
+	if _, err := relay.Subscribe(webhook.Subscriber{URL: subscriber.URL, EventTypes: []string{"requesttopay.*"}}); err != nil {
+		t.Fatal(err)
+	}
+	client := momo.NewClientFromConfig(momo.Config{
+		Environment: "sandbox",
+		BaseURL:     upstream.URL,
+		Collection:  momo.Credentials{SubscriptionKey: "test-subscription-key", ApiUserID: "test-api-user-id", ApiKey: "test-api-key"},
+	})
+	gateway := New(Config{AllowAnonymous: true, Relay: relay, StatusPollInterval: 10 * time.Millisecond}, client)
+
+	// Le flux se termine une fois le statut final observé par interrogation
+	if rec := serve(gateway, http.MethodGet, "/payments/"+referenceID+"/events", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	relay.Close()
+
+	select {
+	case event := <-delivered:
+		if event.Type != "requesttopay.successful" || event.Data.ReferenceID != referenceID || event.Data.Source != webhook.SourcePoll {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("expected the polled status to be relayed")
+	}
+	if len(delivered) != 0 {
+		t.Fatal("expected a single event")
+	}
+}
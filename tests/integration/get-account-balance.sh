@@ -8,26 +8,13 @@ fi
 
 source "$env_file"
 
-# Charger le TOKEN extrait
-token=$(cat /tmp/momo_auth_token)
-
-# Vérifier si le TOKEN est vide
-if [ -z "$token" ]; then
-    echo "TOKEN is missing"
-    exit 1
-fi
-
-# Afficher les variables d'environnement chargées et le TOKEN
-echo "TOKEN: $token"
-echo "SUBSCRIPTION_KEY: $SUBSCRIPTION_KEY"
+# Afficher les variables d'environnement chargées
 echo "ENVIRONMENT: $ENVIRONMENT"
 
 # Exécuter la requête avec des détails de débogage
 response=$(curl -s -X GET "http://localhost:8080/get-account-balance" \
-    -H "Authorization: Bearer $token" \
-    -H "X-Target-Environment: $ENVIRONMENT" \
-    -H "Cache-Control: no-cache" \
-    -H "Ocp-Apim-Subscription-Key: $SUBSCRIPTION_KEY")
+    -H "X-Api-Key: $GATEWAY_API_KEY" \
+    -H "Cache-Control: no-cache")
 
 # Afficher la réponse
 echo "Response: $response"
@@ -12,9 +12,6 @@ source "$env_file"
 # Charger le X-Reference-Id-requesttopay
 x_reference_id=$(cat /tmp/X-Reference-Id-requesttopay)
 
-# Charger le token d'authentification
-token=$(cat /tmp/momo_auth_token)
-
 # Vérifier si le X-Reference-Id-requesttopay est vide
 if [ -z "$x_reference_id" ]; then
     echo "X-Reference-Id-requesttopay is missing"
@@ -22,16 +19,12 @@ if [ -z "$x_reference_id" ]; then
 fi
 
 # Afficher les variables d'environnement et les valeurs chargées
-echo "SUBSCRIPTION_KEY: $SUBSCRIPTION_KEY"
 echo "X-Reference-Id-requesttopay: $x_reference_id"
-echo "momo_auth_token: $token"
 echo "ENVIRONMENT: $ENVIRONMENT"
 
 # Effectuer la requête cURL pour obtenir le statut du paiement
 response=$(curl -s -X GET "http://localhost:8080/payment-status/$x_reference_id" \
-            -H "Authorization: Bearer $token" \
-            -H "X-Target-Environment: $ENVIRONMENT" \
-            -H "Ocp-Apim-Subscription-Key: $SUBSCRIPTION_KEY")
+            -H "X-Api-Key: $GATEWAY_API_KEY")
 
 # Afficher la réponse
 echo "Response: $response"
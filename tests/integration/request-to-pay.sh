@@ -14,15 +14,6 @@ if ! command -v jq &> /dev/null; then
     exit 1
 fi
 
-# Fonction pour obtenir le jeton d'authentification
-get_auth_token() {
-    ./tests/integration/get-auth-token.sh
-    if [ ! -f /tmp/momo_auth_token ]; then
-        echo "Erreur: impossible d'obtenir le jeton d'authentification"
-        exit 1
-    fi
-}
-
 # Fonction pour créer un utilisateur API
 create_api_user() {
     ./tests/integration/create-api-user.sh
@@ -32,21 +23,6 @@ create_api_user() {
     fi
 }
 
-# Vérifier si le fichier de jeton d'authentification existe, sinon obtenir un nouveau jeton
-if [ ! -f /tmp/momo_auth_token ]; then
-    echo "Le fichier de jeton d'authentification n'existe pas. Obtention d'un nouveau jeton..."
-    get_auth_token
-fi
-
-token=$(cat /tmp/momo_auth_token)
-echo "Token: $token"
-
-# Vérifier si le jeton est vide
-if [ -z "$token" ]; then
-  echo "Erreur: le jeton d'authentification est vide"
-  exit 1
-fi
-
 # Vérifier si le fichier de reference ID existe, sinon créer un nouvel utilisateur API
 if [ ! -f /tmp/momo_reference_id ]; then
     echo "Le fichier de reference ID n'existe pas. Création d'un nouvel utilisateur API..."
@@ -59,14 +35,10 @@ echo "Reference ID: $reference_id"
 # Afficher les valeurs d'environnement pour le débogage
 echo "BASE_URL: $BASE_URL"
 echo "Environment: $ENVIRONMENT"
-echo "Subscription Key: $SUBSCRIPTION_KEY"
 
 # Créer la requête de paiement
 response=$(curl -s -w "\nHTTP_STATUS_CODE:%{http_code}\n" -X POST "$BASE_URL/request-to-pay" \
-    -H "Authorization: Bearer $token" \
-    -H "X-Reference-Id: $reference_id" \
-    -H "X-Target-Environment: $ENVIRONMENT" \
-    -H "Ocp-Apim-Subscription-Key: $SUBSCRIPTION_KEY" \
+    -H "X-Api-Key: $GATEWAY_API_KEY" \
     -H "Content-Type: application/json" \
     -H "Cache-Control: no-cache" \
     -d '{
@@ -124,5 +96,3 @@ else
     exit 1
 fi
 
-# Stocker à nouveau le token au cas où il serait utilisé plus tard
-echo $token > /tmp/momo_auth_token
@@ -0,0 +1,418 @@
+// Package webhook relaie les résultats de paiement MoMo vers des abonnés internes,
+// sous forme d'événements JSON versionnés et signés en HMAC-SHA256.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+	"github.com/google/uuid"
+)
+
+// Version du format des événements envoyés aux abonnés
+const EventVersion = "1"
+
+// En-têtes ajoutés à chaque livraison
+const (
+	HeaderSignature = "X-Momo-Signature"
+	HeaderTimestamp = "X-Momo-Timestamp"
+	HeaderEventID   = "X-Momo-Event-Id"
+	HeaderEventType = "X-Momo-Event-Type"
+)
+
+// Sources possibles d'un changement de statut
+const (
+	SourceCallback = "callback"
+	SourcePoll     = "poll"
+)
+
+// Valeurs par défaut des nouvelles tentatives
+const (
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+	defaultTimeout        = 10 * time.Second
+	defaultMaxDeadLetters = 1000
+)
+
+var (
+	// ErrUnknownSubscriber signale un abonné inexistant
+	ErrUnknownSubscriber = errors.New("unknown subscriber")
+	// ErrUnknownDeadLetter signale une lettre morte inexistante
+	ErrUnknownDeadLetter = errors.New("unknown dead letter")
+)
+
+// Structure pour un événement envoyé aux abonnés
+type Event struct {
+	ID        string    `json:"id"`
+	Version   string    `json:"version"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      EventData `json:"data"`
+}
+
+// Structure pour le contenu d'un événement
+type EventData struct {
+	ReferenceID string            `json:"referenceId"`
+	Operation   momo.CallbackType `json:"operation"`
+	Status      string            `json:"status"`
+	Source      string            `json:"source"`
+	Result      interface{}       `json:"result,omitempty"`
+}
+
+// Structure pour un abonné interne. EventTypes accepte des types exacts
+// (requesttopay.successful), des jokers par opération (requesttopay.*) ou "*".
+type Subscriber struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Secret     string   `json:"secret,omitempty"`
+}
+
+// Structure pour une livraison abandonnée après toutes les tentatives
+type DeadLetter struct {
+	ID           string    `json:"id"`
+	Event        Event     `json:"event"`
+	SubscriberID string    `json:"subscriberId"`
+	URL          string    `json:"url"`
+	Attempts     int       `json:"attempts"`
+	LastError    string    `json:"lastError"`
+	FailedAt     time.Time `json:"failedAt"`
+}
+
+// Relay diffuse les événements aux abonnés, avec nouvelles tentatives à délai exponentiel
+// puis mise en liste des lettres mortes. Au-delà de MaxDeadLetters, les plus anciennes
+// lettres mortes sont oubliées.
+type Relay struct {
+	HTTPClient     *http.Client
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxDeadLetters int
+
+	mu          sync.Mutex
+	subscribers map[string]Subscriber
+	deadLetters []DeadLetter
+	closed      bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRelay() *Relay {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Relay{
+		HTTPClient:     &http.Client{Timeout: defaultTimeout},
+		MaxAttempts:    defaultMaxAttempts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		MaxDeadLetters: defaultMaxDeadLetters,
+		subscribers:    make(map[string]Subscriber),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// Subscribe enregistre un abonné. Un identifiant et un secret sont générés s'ils sont vides ;
+// le secret n'est renvoyé que par cet appel.
+func (r *Relay) Subscribe(s Subscriber) (Subscriber, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscriber{}, fmt.Errorf("invalid subscriber URL %q", s.URL)
+	}
+	if len(s.EventTypes) == 0 {
+		return Subscriber{}, errors.New("at least one event type is required")
+	}
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return Subscriber{}, err
+		}
+		s.Secret = hex.EncodeToString(secret)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers[s.ID] = s
+	return s, nil
+}
+
+func (r *Relay) Unsubscribe(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subscribers[id]; !ok {
+		return fmt.Errorf("%w %s", ErrUnknownSubscriber, id)
+	}
+	delete(r.subscribers, id)
+	return nil
+}
+
+// Subscribers renvoie les abonnés, sans leur secret.
+func (r *Relay) Subscribers() []Subscriber {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscribers := make([]Subscriber, 0, len(r.subscribers))
+	for _, s := range r.subscribers {
+		s.Secret = ""
+		subscribers = append(subscribers, s)
+	}
+	return subscribers
+}
+
+// DeadLetters renvoie les livraisons abandonnées.
+func (r *Relay) DeadLetters() []DeadLetter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]DeadLetter(nil), r.deadLetters...)
+}
+
+// Redeliver retire une lettre morte de la liste et relance sa livraison.
+func (r *Relay) Redeliver(id string) error {
+	r.mu.Lock()
+	var letter *DeadLetter
+	for i := range r.deadLetters {
+		if r.deadLetters[i].ID == id {
+			found := r.deadLetters[i]
+			letter = &found
+			r.deadLetters = append(r.deadLetters[:i], r.deadLetters[i+1:]...)
+			break
+		}
+	}
+	subscriber, ok := Subscriber{}, false
+	if letter != nil {
+		subscriber, ok = r.subscribers[letter.SubscriberID]
+	}
+	r.mu.Unlock()
+
+	if letter == nil {
+		return fmt.Errorf("%w %s", ErrUnknownDeadLetter, id)
+	}
+	if !ok {
+		return fmt.Errorf("%w %s: it no longer exists", ErrUnknownSubscriber, letter.SubscriberID)
+	}
+	r.deliverAsync(subscriber, letter.Event)
+	return nil
+}
+
+// PublishCallback publie un callback MoMo. Elle peut servir de momo.CallbackFunc.
+func (r *Relay) PublishCallback(ctx context.Context, event momo.CallbackEvent) error {
+	meta := event.Meta()
+	r.Publish(NewEvent(meta.Type, meta.ReferenceID, event.Status(), SourceCallback, momo.CallbackResult(event)))
+	return nil
+}
+
+// PublishFinal publie l'événement final d'un momo.Tracker. Elle peut servir de fonction onFinal.
+func (r *Relay) PublishFinal(ctx context.Context, event momo.TrackerEvent) {
+	source := SourcePoll
+	if event.Source == momo.ResolvedByCallback {
+		source = SourceCallback
+	}
+	r.Publish(NewEvent(event.Pending.Type, event.Pending.ReferenceID, event.Status, source, event.Result))
+}
+
+// PublishStatus publie un changement de statut observé par interrogation de MoMo.
+func (r *Relay) PublishStatus(operation momo.CallbackType, referenceID, status string, result interface{}) {
+	r.Publish(NewEvent(operation, referenceID, status, SourcePoll, result))
+}
+
+// Publish envoie l'événement à chaque abonné concerné, sans attendre la fin des livraisons.
+func (r *Relay) Publish(event Event) {
+	r.mu.Lock()
+	var targets []Subscriber
+	for _, s := range r.subscribers {
+		if matches(s.EventTypes, event.Type) {
+			targets = append(targets, s)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, s := range targets {
+		r.deliverAsync(s, event)
+	}
+}
+
+// Shutdown attend la fin des livraisons en cours, nouvelles tentatives comprises.
+// Si ctx expire avant, les tentatives restantes sont abandonnées en lettres mortes.
+// Les événements publiés ensuite vont directement en lettres mortes.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Close attend la fin de toutes les livraisons en cours.
+func (r *Relay) Close() {
+	r.Shutdown(context.Background())
+}
+
+func NewEvent(operation momo.CallbackType, referenceID, status, source string, result interface{}) Event {
+	return Event{
+		ID:        uuid.New().String(),
+		Version:   EventVersion,
+		Type:      EventType(operation, status),
+		CreatedAt: time.Now().UTC(),
+		Data: EventData{
+			ReferenceID: referenceID,
+			Operation:   operation,
+			Status:      status,
+			Source:      source,
+			Result:      result,
+		},
+	}
+}
+
+// EventType construit le type d'événement, par exemple requesttopay.successful.
+func EventType(operation momo.CallbackType, status string) string {
+	return string(operation) + "." + strings.ToLower(status)
+}
+
+func matches(patterns []string, eventType string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, ".*"); ok && strings.HasPrefix(eventType, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverAsync démarre une livraison. wg.Add est appelé sous r.mu, après le contrôle de
+// r.closed, pour ne jamais croiser le wg.Wait de Shutdown.
+func (r *Relay) deliverAsync(s Subscriber, event Event) {
+	r.mu.Lock()
+	if r.closed {
+		r.addDeadLetter(s, event, 0, errors.New("relay is shut down"))
+		r.mu.Unlock()
+		return
+	}
+	r.wg.Add(1)
+	r.mu.Unlock()
+
+	go func() {
+		defer r.wg.Done()
+		r.deliver(s, event)
+	}()
+}
+
+func (r *Relay) deliver(s Subscriber, event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error marshaling webhook event %s: %v", event.ID, err)
+		return
+	}
+
+	var lastErr error
+	attempt := 0
+	for attempt < r.MaxAttempts {
+		attempt++
+		if lastErr = r.send(s, event, body); lastErr == nil {
+			log.Printf("Webhook event %s delivered to %s", event.ID, s.URL)
+			return
+		}
+		log.Printf("Webhook event %s delivery to %s failed (attempt %d/%d): %v", event.ID, s.URL, attempt, r.MaxAttempts, lastErr)
+
+		if attempt == r.MaxAttempts {
+			break
+		}
+		select {
+		case <-time.After(r.backoff(attempt)):
+		case <-r.ctx.Done():
+			lastErr = fmt.Errorf("relay closed after attempt %d: %w", attempt, lastErr)
+			attempt = r.MaxAttempts
+		}
+	}
+
+	r.mu.Lock()
+	r.addDeadLetter(s, event, attempt, lastErr)
+	r.mu.Unlock()
+}
+
+// addDeadLetter ajoute une lettre morte en oubliant les plus anciennes au-delà de
+// MaxDeadLetters. r.mu doit être verrouillé.
+func (r *Relay) addDeadLetter(s Subscriber, event Event, attempts int, err error) {
+	log.Printf("Webhook event %s to %s moved to dead letters: %v", event.ID, s.URL, err)
+	r.deadLetters = append(r.deadLetters, DeadLetter{
+		ID:           uuid.New().String(),
+		Event:        event,
+		SubscriberID: s.ID,
+		URL:          s.URL,
+		Attempts:     attempts,
+		LastError:    err.Error(),
+		FailedAt:     time.Now().UTC(),
+	})
+	if r.MaxDeadLetters > 0 && len(r.deadLetters) > r.MaxDeadLetters {
+		dropped := len(r.deadLetters) - r.MaxDeadLetters
+		r.deadLetters = append([]DeadLetter(nil), r.deadLetters[dropped:]...)
+	}
+}
+
+func (r *Relay) send(s Subscriber, event Event, body []byte) error {
+	ctx, cancel := context.WithTimeout(r.ctx, defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, event.ID)
+	req.Header.Set(HeaderEventType, event.Type)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(timestamp))
+	req.Header.Set(HeaderSignature, Sign(s.Secret, timestamp, body))
+
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("subscriber responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.InitialBackoff << (attempt - 1)
+	if delay <= 0 || delay > r.MaxBackoff {
+		return r.MaxBackoff
+	}
+	return delay
+}
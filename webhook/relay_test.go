@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/enzoforreal/mtn-momo-api/momo"
+)
+
+func TestRelayDeliversSignedEvents(t *testing.T) {
+	received := make(chan Event, 1)
+	var secret string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature), time.Minute); err != nil {
+			t.Errorf("invalid signature: %v", err)
+		}
+		var event Event
+		json.Unmarshal(body, &event)
+		received <- event
+	}))
+	defer ts.Close()
+
+	relay := NewRelay()
+	defer relay.Close()
+
+	subscriber, err := relay.Subscribe(Subscriber{URL: ts.URL, EventTypes: []string{"requesttopay.*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret = subscriber.Secret
+
+	relay.PublishCallback(context.Background(), momo.RequestToPayEvent{
+		CallbackMeta: momo.CallbackMeta{Type: momo.CallbackRequestToPay, ReferenceID: "test-reference-id"},
+		Result:       momo.RequestToPayResult{Status: momo.StatusSuccessful},
+	})
+	relay.PublishStatus(momo.CallbackTransfer, "other-reference-id", momo.StatusSuccessful, nil)
+
+	select {
+	case event := <-received:
+		if event.Type != "requesttopay.successful" || event.Version != EventVersion {
+			t.Fatalf("unexpected event %+v", event)
+		}
+		if event.Data.ReferenceID != "test-reference-id" || event.Data.Source != SourceCallback {
+			t.Fatalf("unexpected event data %+v", event.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	relay.Close()
+	if len(received) != 0 {
+		t.Fatal("expected the transfer event not to be delivered")
+	}
+}
+
+func TestRelayDeadLettersAfterRetries(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	relay := NewRelay()
+	relay.MaxAttempts = 3
+	relay.InitialBackoff = time.Millisecond
+	relay.MaxBackoff = 5 * time.Millisecond
+
+	if _, err := relay.Subscribe(Subscriber{URL: ts.URL, EventTypes: []string{"*"}}); err != nil {
+		t.Fatal(err)
+	}
+	relay.PublishStatus(momo.CallbackRequestToPay, "test-reference-id", momo.StatusFailed, nil)
+	relay.Close()
+
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+	letters := relay.DeadLetters()
+	if len(letters) != 1 {
+		t.Fatalf("expected one dead letter, got %d", len(letters))
+	}
+	if letters[0].Attempts != 3 || letters[0].Event.Type != "requesttopay.failed" {
+		t.Fatalf("unexpected dead letter %+v", letters[0])
+	}
+}
+
+func TestRelayCapsDeadLettersAndStopsAfterShutdown(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	relay := NewRelay()
+	relay.MaxDeadLetters = 2
+	if _, err := relay.Subscribe(Subscriber{URL: ts.URL, EventTypes: []string{"*"}}); err != nil {
+		t.Fatal(err)
+	}
+	relay.Close()
+
+	for _, referenceID := range []string{"ref-1", "ref-2", "ref-3"} {
+		relay.PublishStatus(momo.CallbackRequestToPay, referenceID, momo.StatusSuccessful, nil)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 0 {
+		t.Fatalf("expected no delivery after shutdown, got %d", got)
+	}
+	letters := relay.DeadLetters()
+	if len(letters) != 2 {
+		t.Fatalf("expected two dead letters, got %d", len(letters))
+	}
+	if letters[0].Event.Data.ReferenceID != "ref-2" || letters[1].Event.Data.ReferenceID != "ref-3" {
+		t.Fatalf("expected the oldest dead letter to be dropped, got %s and %s", letters[0].Event.Data.ReferenceID, letters[1].Event.Data.ReferenceID)
+	}
+}
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign calcule la signature HMAC-SHA256 de "<timestamp>.<body>" avec le secret de l'abonné.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify contrôle la signature et l'horodatage d'une livraison reçue par un abonné.
+// Une tolérance nulle désactive le contrôle de l'horodatage.
+func Verify(secret, timestamp string, body []byte, signature string, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if tolerance > 0 {
+		if age := time.Since(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+			return errors.New("timestamp outside of tolerance")
+		}
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return errors.New("unsupported signature format")
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}